@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	NONE string = "none"
+	HMAC string = "hmac"
+	MTLS string = "mtls"
+)
+
+const (
+	HEADER_KEY_ID    string = "X-ABC-Key-Id"
+	HEADER_TIMESTAMP string = "X-ABC-Timestamp"
+	HEADER_NONCE     string = "X-ABC-Nonce"
+	HEADER_SIGNATURE string = "X-ABC-Signature"
+)
+
+// Signer adds authentication to a request sent to a player (controller).
+type Signer interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+func NewSigner(mode string, keyId string, secret string) (Signer, error) {
+	switch mode {
+	case "", HMAC:
+		return NewHMACSigner(keyId, secret)
+	case MTLS:
+		// client certificate 는 http client 의 TLS 설정으로 전달됩니다.
+		return noneSigner{}, nil
+	case NONE:
+		log.Printf("[WARN] AUTH_MODE is none. requests to players are not signed")
+		return noneSigner{}, nil
+	}
+	return nil, fmt.Errorf("unsupported auth mode: %s", mode)
+}
+
+// HMACSigner signs requests the way the controller's HMACAuthenticator verifies them.
+//
+// signature = hex(HMAC-SHA256(secret, method \n requestURI \n timestamp \n nonce \n hex(sha256(body))))
+type HMACSigner struct {
+	keyId  string
+	secret []byte
+}
+
+func NewHMACSigner(keyId string, secret string) (*HMACSigner, error) {
+	// controller 는 key id 가 다르면 모든 요청을 거부하므로 시작할 때 확인합니다.
+	if keyId == "" {
+		return nil, fmt.Errorf("AUTH_HMAC_KEY_ID is required for hmac auth mode")
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("AUTH_HMAC_SECRET is required for hmac auth mode")
+	}
+	secretBytes, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_HMAC_SECRET must be base64: %w", err)
+	}
+	return &HMACSigner{keyId: keyId, secret: secretBytes}, nil
+}
+
+func (s *HMACSigner) Sign(req *http.Request, body []byte) error {
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set(HEADER_KEY_ID, s.keyId)
+	req.Header.Set(HEADER_TIMESTAMP, timestamp)
+	req.Header.Set(HEADER_NONCE, nonce)
+	req.Header.Set(HEADER_SIGNATURE, Sign(s.secret, req.Method, req.URL.RequestURI(), timestamp, nonce, body))
+	return nil
+}
+
+// Sign returns the hex encoded signature of a request.
+func Sign(secret []byte, method string, requestURI string, timestamp string, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	message := strings.Join([]string{method, requestURI, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type noneSigner struct{}
+
+func (noneSigner) Sign(req *http.Request, body []byte) error {
+	return nil
+}
+
+// ClientTLSConfig returns the TLS config used to call players.
+// certFile and keyFile are the client certificate for mtls. caFile verifies the player's server certificate.
+func ClientTLSConfig(certFile string, keyFile string, caFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if certFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	UNAUTHORIZED      string = "UNAUTHORIZED"
+	REQUEST_TOO_LARGE string = "REQUEST_TOO_LARGE"
+)
+
+// 서명을 확인하기 전에 읽는 callback body 의 최대 크기
+const MAX_BODY_SIZE int64 = 1 << 20
+
+// gin context key of the verified caller
+const identityKey = "auth.identity"
+
+// signed requests older than this are rejected
+const replayWindow = 5 * time.Minute
+
+var (
+	ErrUnauthorized = errors.New(UNAUTHORIZED)
+)
+
+// PlayerKey is the callback key of a player. Each player has its own secret so one player can't sign as the other.
+type PlayerKey struct {
+	PlayerIndex string
+	KeyId       string
+	Secret      string
+}
+
+type playerSecret struct {
+	playerIndex string
+	secret      []byte
+}
+
+// HMACVerifier accepts requests signed by the players (controllers), such as completion callbacks.
+// The key id of a request selects the secret, and the request is attributed to the player of that key.
+type HMACVerifier struct {
+	keys   map[string]playerSecret
+	window time.Duration
+	nonces *nonceCache
+}
+
+func NewHMACVerifier(keys ...PlayerKey) (*HMACVerifier, error) {
+	verifier := &HMACVerifier{
+		keys:   make(map[string]playerSecret),
+		window: replayWindow,
+		nonces: newNonceCache(2 * replayWindow),
+	}
+	for _, key := range keys {
+		if key.KeyId == "" || key.Secret == "" {
+			return nil, fmt.Errorf("PLAYER%s_CALLBACK_HMAC_KEY_ID and PLAYER%s_CALLBACK_HMAC_SECRET are required", key.PlayerIndex, key.PlayerIndex)
+		}
+		if _, ok := verifier.keys[key.KeyId]; ok {
+			return nil, fmt.Errorf("callback key id is used by more than one player: %s", key.KeyId)
+		}
+		secretBytes, err := base64.StdEncoding.DecodeString(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("PLAYER%s_CALLBACK_HMAC_SECRET must be base64: %w", key.PlayerIndex, err)
+		}
+		verifier.keys[key.KeyId] = playerSecret{playerIndex: key.PlayerIndex, secret: secretBytes}
+	}
+	return verifier, nil
+}
+
+// Verify returns the player index of the signer.
+func (v *HMACVerifier) Verify(r *http.Request, body []byte) (string, error) {
+	keyId := r.Header.Get(HEADER_KEY_ID)
+	timestamp := r.Header.Get(HEADER_TIMESTAMP)
+	nonce := r.Header.Get(HEADER_NONCE)
+	signature := r.Header.Get(HEADER_SIGNATURE)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return "", fmt.Errorf("%w: request is not signed", ErrUnauthorized)
+	}
+	key, ok := v.keys[keyId]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown key id: %s", ErrUnauthorized, keyId)
+	}
+
+	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid timestamp", ErrUnauthorized)
+	}
+	skew := time.Since(time.Unix(unixTime, 0))
+	if skew > v.window || skew < -v.window {
+		return "", fmt.Errorf("%w: timestamp is outside of the replay window", ErrUnauthorized)
+	}
+
+	expected := Sign(key.secret, r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return "", fmt.Errorf("%w: invalid signature", ErrUnauthorized)
+	}
+
+	// 서명이 맞는 요청만 nonce 를 기록해야 임의의 nonce 로 cache 를 채울 수 없습니다.
+	if !v.nonces.add(nonce) {
+		return "", fmt.Errorf("%w: replayed request", ErrUnauthorized)
+	}
+
+	return key.playerIndex, nil
+}
+
+// Middleware rejects requests the verifier doesn't accept with 401 and bodies larger than MAX_BODY_SIZE with 413.
+func Middleware(verifier *HMACVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MAX_BODY_SIZE))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": gin.H{
+					"text":    REQUEST_TOO_LARGE,
+					"message": err.Error(),
+				}})
+				return
+			}
+			abort(c, err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		identity, err := verifier.Verify(c.Request, body)
+		if err != nil {
+			log.Printf("[auth] rejected. url: %s, error: %v", c.Request.URL, err)
+			abort(c, err)
+			return
+		}
+
+		c.Set(identityKey, identity)
+		c.Next()
+	}
+}
+
+// Identity returns the player index of the caller set by Middleware.
+func Identity(c *gin.Context) string {
+	return c.GetString(identityKey)
+}
+
+func abort(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": gin.H{
+		"text":    UNAUTHORIZED,
+		"message": err.Error(),
+	}})
+}
+
+type nonceCache struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	nonces map[string]time.Time
+}
+
+func newNonceCache(ttl time.Duration) *nonceCache {
+	return &nonceCache{ttl: ttl, nonces: make(map[string]time.Time)}
+}
+
+// add returns false if the nonce has been seen within the ttl.
+func (n *nonceCache) add(nonce string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	for seen, expiresAt := range n.nonces {
+		if now.After(expiresAt) {
+			delete(n.nonces, seen)
+		}
+	}
+
+	if _, ok := n.nonces[nonce]; ok {
+		return false
+	}
+	n.nonces[nonce] = now.Add(n.ttl)
+	return true
+}
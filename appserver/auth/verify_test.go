@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	player1Secret = []byte("player1 secret 0123456789abcdef")
+	player2Secret = []byte("player2 secret 0123456789abcdef")
+)
+
+func newTestVerifier(t *testing.T) *HMACVerifier {
+	t.Helper()
+	verifier, err := NewHMACVerifier(
+		PlayerKey{PlayerIndex: "1", KeyId: "controller1", Secret: base64.StdEncoding.EncodeToString(player1Secret)},
+		PlayerKey{PlayerIndex: "2", KeyId: "controller2", Secret: base64.StdEncoding.EncodeToString(player2Secret)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+func signedCallback(t *testing.T, keyId string, secret []byte, body []byte) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/v1/tsm/callbacks/sessions", bytes.NewReader(body))
+	signer, err := NewHMACSigner(keyId, base64.StdEncoding.EncodeToString(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signer.Sign(req, body); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestNewHMACVerifierRejectsInvalidKeys(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(player1Secret)
+	tests := map[string][]PlayerKey{
+		"empty key id":     {{PlayerIndex: "1", Secret: secret}},
+		"empty secret":     {{PlayerIndex: "1", KeyId: "controller1"}},
+		"not base64":       {{PlayerIndex: "1", KeyId: "controller1", Secret: "not base64!"}},
+		"duplicate key id": {{PlayerIndex: "1", KeyId: "controller", Secret: secret}, {PlayerIndex: "2", KeyId: "controller", Secret: secret}},
+	}
+	for name, keys := range tests {
+		if _, err := NewHMACVerifier(keys...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNewHMACSignerRejectsInvalidConfig(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(player1Secret)
+	tests := map[string][2]string{
+		"empty key id": {"", secret},
+		"empty secret": {"appserver", ""},
+		"not base64":   {"appserver", "not base64!"},
+	}
+	for name, test := range tests {
+		if _, err := NewHMACSigner(test[0], test[1]); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestHMACVerifierReturnsPlayerOfKey(t *testing.T) {
+	verifier := newTestVerifier(t)
+	body := []byte(`{"sessionId":"s","playerIndex":"2"}`)
+
+	playerIndex, err := verifier.Verify(signedCallback(t, "controller2", player2Secret, body), body)
+	if err != nil {
+		t.Fatalf("Verify error: %v", err)
+	}
+	if playerIndex != "2" {
+		t.Errorf("playerIndex = %s, want 2", playerIndex)
+	}
+}
+
+func TestHMACVerifierRejectsInvalidRequests(t *testing.T) {
+	verifier := newTestVerifier(t)
+	body := []byte(`{"sessionId":"s","playerIndex":"2"}`)
+	withoutKeyId := signedCallback(t, "controller2", player2Secret, body)
+	withoutKeyId.Header.Del(HEADER_KEY_ID)
+
+	tests := map[string]struct {
+		req  *http.Request
+		body []byte
+	}{
+		"unsigned":       {httptest.NewRequest("POST", "/v1/tsm/callbacks/sessions", bytes.NewReader(body)), body},
+		"unknown key id": {signedCallback(t, "controller3", player2Secret, body), body},
+		"other secret":   {signedCallback(t, "controller2", player1Secret, body), body},
+		"changed body":   {signedCallback(t, "controller2", player2Secret, body), []byte(`{"sessionId":"s","playerIndex":"1"}`)},
+		"empty key id":   {withoutKeyId, body},
+	}
+	for name, test := range tests {
+		if _, err := verifier.Verify(test.req, test.body); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("%s: error = %v, want %v", name, err, ErrUnauthorized)
+		}
+	}
+}
+
+func TestHMACVerifierRejectsReplayedRequest(t *testing.T) {
+	verifier := newTestVerifier(t)
+	body := []byte(`{}`)
+	req := signedCallback(t, "controller1", player1Secret, body)
+
+	if _, err := verifier.Verify(req, body); err != nil {
+		t.Fatalf("first request error: %v", err)
+	}
+	if _, err := verifier.Verify(req, body); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("replayed request error = %v, want %v", err, ErrUnauthorized)
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	nonces := newNonceCache(time.Hour)
+	if !nonces.add("a") {
+		t.Error("first nonce was rejected")
+	}
+	if nonces.add("a") {
+		t.Error("repeated nonce was accepted")
+	}
+
+	expiring := newNonceCache(-time.Second)
+	expiring.add("a")
+	if !expiring.add("a") {
+		t.Error("expired nonce was rejected")
+	}
+	if len(expiring.nonces) != 1 {
+		t.Errorf("expired nonces are kept: %d", len(expiring.nonces))
+	}
+}
+
+func TestMiddlewareRejectsLargeBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/v1/tsm/callbacks/sessions", Middleware(newTestVerifier(t)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	body := bytes.Repeat([]byte("a"), int(MAX_BODY_SIZE)+1)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, signedCallback(t, "controller1", player1Secret, body))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ahnlabio/tsm-appserver/tsmutils"
+)
+
+// ersverify checks offline that ERS recovery data exported from the appserver can recover the key.
+// The public key must be obtained independently, e.g. the pkix of /v1/tsm/keys/{keyId}/publicKey or Schnorr().PublicKey,
+// so a recovery file for another key is not accepted. The key is not reconstructed.
+//
+//	go run ./cmd/ersverify recovery.json MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg=
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: ersverify <recovery data file> <base64 pkix public key>")
+		os.Exit(2)
+	}
+
+	packageBytes, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read recovery data: %v\n", err)
+		os.Exit(2)
+	}
+	var recoveryPackage tsmutils.RecoveryPackage
+	if err := json.Unmarshal(packageBytes, &recoveryPackage); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse recovery data: %v\n", err)
+		os.Exit(2)
+	}
+
+	pkixPublicKey, err := base64.StdEncoding.DecodeString(os.Args[2])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "public key is not base64: %v\n", err)
+		os.Exit(2)
+	}
+
+	if err := recoveryPackage.Validate(pkixPublicKey); err != nil {
+		fmt.Fprintf(os.Stderr, "INVALID: %v\n", err)
+		os.Exit(1)
+	}
+
+	// recovery data 를 복구할 수 있는 ERS key 가 맞는지 운영자가 확인할 수 있도록 출력합니다.
+	fingerprint, err := tsmutils.ERSKeyFingerprint(recoveryPackage.ERSPublicKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "INVALID: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("OK: keyId: %s, algorithm: %s, ERS key: %s, label: %q\n", recoveryPackage.KeyId, recoveryPackage.Algorithm, fingerprint, recoveryPackage.ERSLabel)
+}
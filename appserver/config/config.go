@@ -8,6 +8,27 @@ type Config struct {
 	BuildType  string `env:"BUILD_TYPE"`
 	Player1Url string `env:"PLAYER1_URL"`
 	Player2Url string `env:"PLAYER2_URL"`
+
+	AuthMode          string `env:"AUTH_MODE"`
+	AuthHMACKeyId     string `env:"AUTH_HMAC_KEY_ID"`
+	AuthHMACSecret    string `env:"AUTH_HMAC_SECRET"`
+	TLSClientCertFile string `env:"TLS_CLIENT_CERT_FILE"`
+	TLSClientKeyFile  string `env:"TLS_CLIENT_KEY_FILE"`
+	TLSCAFile         string `env:"TLS_CA_FILE"`
+
+	// player 마다 다른 secret 을 사용해야 한 player 가 다른 player 의 callback 을 보낼 수 없습니다.
+	Player1CallbackHMACKeyId  string `env:"PLAYER1_CALLBACK_HMAC_KEY_ID"`
+	Player1CallbackHMACSecret string `env:"PLAYER1_CALLBACK_HMAC_SECRET"`
+	Player2CallbackHMACKeyId  string `env:"PLAYER2_CALLBACK_HMAC_KEY_ID"`
+	Player2CallbackHMACSecret string `env:"PLAYER2_CALLBACK_HMAC_SECRET"`
+
+	// 비어 있으면 key metadata 를 memory 에만 저장합니다. sqlite3 또는 postgres
+	KeyMetadataDBDriver string `env:"KEY_METADATA_DB_DRIVER"`
+	KeyMetadataDBDSN    string `env:"KEY_METADATA_DB_DSN"`
+
+	// 비어 있으면 revocation 기록을 memory 에만 저장합니다. sqlite3 또는 postgres
+	RevocationDBDriver string `env:"REVOCATION_DB_DRIVER"`
+	RevocationDBDSN    string `env:"REVOCATION_DB_DSN"`
 }
 
 func GetConfig() *Config {
@@ -17,5 +38,23 @@ func GetConfig() *Config {
 		BuildType:  os.Getenv("BUILD_TYPE"),
 		Player1Url: os.Getenv("PLAYER1_URL"),
 		Player2Url: os.Getenv("PLAYER2_URL"),
+
+		AuthMode:          os.Getenv("AUTH_MODE"),
+		AuthHMACKeyId:     os.Getenv("AUTH_HMAC_KEY_ID"),
+		AuthHMACSecret:    os.Getenv("AUTH_HMAC_SECRET"),
+		TLSClientCertFile: os.Getenv("TLS_CLIENT_CERT_FILE"),
+		TLSClientKeyFile:  os.Getenv("TLS_CLIENT_KEY_FILE"),
+		TLSCAFile:         os.Getenv("TLS_CA_FILE"),
+
+		Player1CallbackHMACKeyId:  os.Getenv("PLAYER1_CALLBACK_HMAC_KEY_ID"),
+		Player1CallbackHMACSecret: os.Getenv("PLAYER1_CALLBACK_HMAC_SECRET"),
+		Player2CallbackHMACKeyId:  os.Getenv("PLAYER2_CALLBACK_HMAC_KEY_ID"),
+		Player2CallbackHMACSecret: os.Getenv("PLAYER2_CALLBACK_HMAC_SECRET"),
+
+		KeyMetadataDBDriver: os.Getenv("KEY_METADATA_DB_DRIVER"),
+		KeyMetadataDBDSN:    os.Getenv("KEY_METADATA_DB_DSN"),
+
+		RevocationDBDriver: os.Getenv("REVOCATION_DB_DRIVER"),
+		RevocationDBDSN:    os.Getenv("REVOCATION_DB_DSN"),
 	}
 }
@@ -2,18 +2,26 @@ package container
 
 import (
 	"log"
+	"net/http"
 
+	"github.com/ahnlabio/tsm-appserver/auth"
 	"github.com/ahnlabio/tsm-appserver/config"
 	"github.com/ahnlabio/tsm-appserver/handlers"
+	"github.com/ahnlabio/tsm-appserver/keymetadata"
+	"github.com/ahnlabio/tsm-appserver/metrics"
+	"github.com/ahnlabio/tsm-appserver/revocation"
+	"github.com/ahnlabio/tsm-appserver/sessiontracker"
 	"github.com/ahnlabio/tsm-appserver/tsmcontroller"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var container *Container
 
 type Container struct {
-	AppConfig     *config.Config
-	TSMController *tsmcontroller.TSMController
-	Handlers      *handlers.Handlers
+	AppConfig        *config.Config
+	TSMController    *tsmcontroller.TSMController
+	Handlers         *handlers.Handlers
+	CallbackVerifier *auth.HMACVerifier
 }
 
 func GetInstnace() *Container {
@@ -22,13 +30,67 @@ func GetInstnace() *Container {
 		appConfig := config.GetConfig()
 		player1 := tsmcontroller.Player{Url: appConfig.Player1Url}
 		player2 := tsmcontroller.Player{Url: appConfig.Player2Url}
-		tsmController := tsmcontroller.NewTSMController(player1, player2)
+		var revocations revocation.Store = revocation.NewMemoryStore()
+		if appConfig.RevocationDBDriver != "" {
+			sqlStore, err := revocation.NewSQLStore(appConfig.RevocationDBDriver, appConfig.RevocationDBDSN)
+			if err != nil {
+				log.Fatalf("failed to open revocation store: %v", err)
+			}
+			revocations = sqlStore
+		} else {
+			log.Printf("[WARN] REVOCATION_DB_DRIVER is empty. revocations are kept in memory only")
+		}
+		var keyMetadata keymetadata.Store = keymetadata.NewMemoryStore()
+		if appConfig.KeyMetadataDBDriver != "" {
+			sqlStore, err := keymetadata.NewSQLStore(appConfig.KeyMetadataDBDriver, appConfig.KeyMetadataDBDSN)
+			if err != nil {
+				log.Fatalf("failed to open key metadata store: %v", err)
+			}
+			keyMetadata = sqlStore
+		} else {
+			log.Printf("[WARN] KEY_METADATA_DB_DRIVER is empty. key metadata is kept in memory only")
+		}
+		signer, err := auth.NewSigner(appConfig.AuthMode, appConfig.AuthHMACKeyId, appConfig.AuthHMACSecret)
+		if err != nil {
+			log.Fatalf("failed to create signer: %v", err)
+		}
+		tlsConfig, err := auth.ClientTLSConfig(appConfig.TLSClientCertFile, appConfig.TLSClientKeyFile, appConfig.TLSCAFile)
+		if err != nil {
+			log.Fatalf("failed to create tls config: %v", err)
+		}
+		// 요청마다 timeout 이 다르므로 TSMController 가 요청의 context 로 정합니다.
+		httpClient := &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+		sessions := sessiontracker.NewTracker()
+		serviceMetrics := metrics.New(prometheus.DefaultRegisterer, sessions.InFlight)
+		tsmController := tsmcontroller.NewTSMController(player1, player2, revocations, keyMetadata, sessions, serviceMetrics, httpClient, signer)
 		handlers := handlers.NewHandler(tsmController)
 
+		// PLAYER1_CALLBACK_HMAC_SECRET, PLAYER2_CALLBACK_HMAC_SECRET 이 없으면 player 의 callback 을 받지 않습니다.
+		var callbackVerifier *auth.HMACVerifier
+		if appConfig.Player1CallbackHMACSecret != "" || appConfig.Player2CallbackHMACSecret != "" {
+			callbackVerifier, err = auth.NewHMACVerifier(
+				auth.PlayerKey{PlayerIndex: "1", KeyId: appConfig.Player1CallbackHMACKeyId, Secret: appConfig.Player1CallbackHMACSecret},
+				auth.PlayerKey{PlayerIndex: "2", KeyId: appConfig.Player2CallbackHMACKeyId, Secret: appConfig.Player2CallbackHMACSecret},
+			)
+			if err != nil {
+				log.Fatalf("failed to create callback verifier: %v", err)
+			}
+		} else {
+			// key metadata 와 copy key lineage 는 session callback 으로 기록하므로 callback 없이는 남지 않습니다.
+			// revoke 는 copy key session 의 callback 으로 기록한 key metadata 에서 device copy 를 확인하므로 callback 없이는 동작하지 않습니다.
+			if appConfig.RevocationDBDriver != "" {
+				log.Fatalf("REVOCATION_DB_DRIVER requires session callbacks. set PLAYER1_CALLBACK_HMAC_SECRET, PLAYER2_CALLBACK_HMAC_SECRET and CALLBACK_URL of the controllers")
+			}
+			log.Printf("[WARN] PLAYER1_CALLBACK_HMAC_SECRET and PLAYER2_CALLBACK_HMAC_SECRET are empty. session callbacks and key revocation are disabled, and key metadata and copy key lineage are not recorded")
+		}
+
 		container = &Container{
-			AppConfig:     appConfig,
-			TSMController: tsmController,
-			Handlers:      handlers,
+			AppConfig:        appConfig,
+			TSMController:    tsmController,
+			Handlers:         handlers,
+			CallbackVerifier: callbackVerifier,
 		}
 	}
 	return container
@@ -37,3 +99,7 @@ func GetInstnace() *Container {
 func (c *Container) GetHandlers() *handlers.Handlers {
 	return c.Handlers
 }
+
+func (c *Container) GetCallbackVerifier() *auth.HMACVerifier {
+	return c.CallbackVerifier
+}
@@ -38,7 +38,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/copyKey": {
+        "/v1/tsm/callbacks/sessions": {
+            "post": {
+                "description": "Called by player1 and player2 when their part of a session succeeds or fails. The request must be HMAC signed with the callback key of the player in playerIndex.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Receive a session completion event",
+                "parameters": [
+                    {
+                        "description": "Completion event",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/sessiontracker.Event"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/sessiontracker.Session"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tsm/copyKey": {
             "post": {
                 "description": "Copy a session key",
                 "consumes": [
@@ -72,7 +106,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/finalizeSign": {
+        "/v1/tsm/finalizeSign": {
             "post": {
                 "description": "Finalize a signature",
                 "consumes": [
@@ -92,7 +126,41 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.FinalizSignRequestBody"
+                            "$ref": "#/definitions/handlers.PartialSignRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PartialSignResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tsm/finalizeSignBatch": {
+            "post": {
+                "description": "Get player partial signatures for up to 100 (preSignatureId, messageHash, keyId) items, e.g. a multi-instruction transaction bundle, in one request.\nItems are independent: a failed item does not stop or roll back the others and carries its error (text and message as in /finalizeSign) instead of partialSignResult.\nThe whole request fails only if the body or an item is malformed (400) or the player cannot be reached (503).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Finalize signatures of a batch of messages",
+                "parameters": [
+                    {
+                        "description": "Sign items",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PartialSignBatchRequestBody"
                         }
                     }
                 ],
@@ -100,13 +168,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.FinalizeSignResponseBody"
+                            "$ref": "#/definitions/handlers.PartialSignBatchResponseBody"
                         }
                     }
                 }
             }
         },
-        "/generateKey": {
+        "/v1/tsm/generateKey": {
             "post": {
                 "description": "Generate a session key",
                 "consumes": [
@@ -140,9 +208,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/preSign": {
+        "/v1/tsm/importKey": {
             "post": {
-                "description": "Pre-sign a message",
+                "description": "Start an import ceremony for an existing private key, e.g. an Ed25519 wallet. The mobile client splits the key into player 0/1/2 shares (tsmutils.ShamirSecretShare), wraps player1's and player2's shares with the keys from /v1/tsm/wrappingKeys (tsmutils.Wrap) and sends them here.\nThen it joins the session with ImportKeyShares and its own share. The session fails unless the shares form the key of pkixPublicKey, so the resulting keyId has the public key of the imported wallet.",
                 "consumes": [
                     "application/json"
                 ],
@@ -152,15 +220,15 @@ const docTemplate = `{
                 "tags": [
                     "session"
                 ],
-                "summary": "Pre-sign a message",
+                "summary": "Import an existing key",
                 "parameters": [
                     {
-                        "description": "Public key and key ID",
+                        "description": "Wrapped key shares and public key",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.PreSignRequestBody"
+                            "$ref": "#/definitions/handlers.ImportKeyRequestBody"
                         }
                     }
                 ],
@@ -168,122 +236,516 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.PreSignReponseBody"
+                            "$ref": "#/definitions/handlers.GenerateKeyResponseBody"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.CopyKeyRequestBody": {
-            "type": "object",
-            "required": [
-                "keyId",
-                "publicKey"
-            ],
-            "properties": {
-                "keyId": {
-                    "type": "string",
-                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
-                },
-                "publicKey": {
-                    "type": "string",
-                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
-                }
-            }
         },
-        "handlers.CopyResponseBody": {
-            "type": "object",
-            "required": [
-                "sessionId"
-            ],
-            "properties": {
-                "sessionId": {
-                    "type": "string"
+        "/v1/tsm/keyMetadata": {
+            "get": {
+                "description": "List the metadata of the keys of a user and/or device public key, oldest first. At least one of userId and devicePublicKey is required. devicePublicKey must be URL encoded.\nKey metadata is recorded from the session callbacks of the players, so the controllers must be configured with CALLBACK_URL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Find the keys of a user or device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device (player 0) public key. base64",
+                        "name": "devicePublicKey",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FindKeyMetadataResponseBody"
+                        }
+                    }
                 }
             }
         },
-        "handlers.FinalizSignRequestBody": {
-            "type": "object",
-            "required": [
-                "keyId",
-                "messageHash",
-                "preSignatureId"
-            ],
-            "properties": {
-                "keyId": {
-                    "type": "string",
-                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
-                },
-                "messageHash": {
-                    "type": "string",
-                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
-                },
-                "preSignatureId": {
-                    "type": "string",
-                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+        "/v1/tsm/keys": {
+            "get": {
+                "description": "List keys held by player 1 and player 2. keys that exist on only one player are flagged as partial.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "List keys of both players",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "limit. max 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tsmcontroller.KeyListResponseBody"
+                        }
+                    }
                 }
             }
         },
-        "handlers.FinalizeSignResponseBody": {
-            "type": "object",
-            "required": [
-                "partialSignResult"
-            ],
-            "properties": {
-                "partialSignResult": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        "/v1/tsm/keys/{keyId}/backup": {
+            "post": {
+                "description": "Export player1's and player2's shares of a key, each encrypted to an operator RSA public key for disaster recovery. The key must be allowed by BACKUP_RECIPIENT_FINGERPRINTS on both players. Fails if either backup fails.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Back up the server shares of a key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
                     },
-                    "example": [
-                        "[\"zUhWR7jvWJoplMyFf35NHSdZXbtx\"]"
-                    ]
+                    {
+                        "description": "Operator public key",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BackupKeySharesRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tsmcontroller.BackupKeySharesResponseBody"
+                        }
+                    }
                 }
             }
         },
-        "handlers.GenerateKeyRequestBody": {
-            "type": "object",
-            "required": [
-                "publicKey"
-            ],
-            "properties": {
-                "publicKey": {
-                    "type": "string",
-                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+        "/v1/tsm/keys/{keyId}/lineage": {
+            "get": {
+                "description": "Get the original key of a key and every key copied from it, with their device public keys and revocations. Keys created before key metadata was recorded only have keyId.\nKey metadata is recorded from the session callbacks of the players, so the controllers must be configured with CALLBACK_URL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Get the lineage of a key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tsmcontroller.KeyLineageResponseBody"
+                        }
+                    }
                 }
             }
         },
-        "handlers.GenerateKeyResponseBody": {
-            "type": "object",
-            "required": [
-                "sessionId"
-            ],
-            "properties": {
-                "sessionId": {
-                    "type": "string"
+        "/v1/tsm/keys/{keyId}/metadata": {
+            "get": {
+                "description": "Get the user, device public key, curve, creation time and source session of a key created through the appserver",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Get the metadata of a key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/keymetadata.KeyMetadata"
+                        }
+                    }
                 }
             }
         },
-        "handlers.PreSignReponseBody": {
-            "type": "object",
-            "required": [
-                "sessionId"
-            ],
-            "properties": {
-                "sessionId": {
-                    "type": "string"
+        "/v1/tsm/keys/{keyId}/publicKey": {
+            "get": {
+                "description": "Get the public key of a key, optionally derived with a non-hardened BIP32 path. hex, base64 and base58 are encodings of the raw public key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Get the public key of a key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "schnorr (default) or ecdsa",
+                        "name": "algorithm",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "non-hardened derivation path. e.g. m/44/501/0",
+                        "name": "derivationPath",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tsmcontroller.PublicKeyResponseBody"
+                        }
+                    }
                 }
             }
         },
-        "handlers.PreSignRequestBody": {
+        "/v1/tsm/keys/{keyId}/recoveryData": {
+            "post": {
+                "description": "Generate Emergency Recovery System data for a key from player1's and player2's shares, encrypted to an ERS RSA public key allowed by ERS_RECIPIENT_FINGERPRINTS on both players.\nThe recovery data is validated against the key's public key before it is returned. Save the response and check it offline with cmd/ersverify. The holder of the ERS private key can recover the private key without the service.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Export ERS recovery data of a key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ERS public key and label",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecoveryDataRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tsmutils.RecoveryPackage"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tsm/keys/{keyId}/revocation": {
+            "get": {
+                "description": "Get who revoked a key and why",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Get the revocation record of a key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/revocation.Revocation"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tsm/keys/{keyId}/revoke": {
+            "post": {
+                "description": "Delete the server side key shares of a device copy on player 1 and player 2 and record who revoked it and why\nThe key must be recorded in key metadata as a copy made for devicePublicKey. Original keys and keys of other devices can't be revoked.\nKey metadata is recorded from the session callbacks of the players, so the controllers must be configured with CALLBACK_URL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Revoke a device key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID of the device copy",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Revoker and reason",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RevokeKeyRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/revocation.Revocation"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tsm/preSign": {
+            "post": {
+                "description": "Pre-sign a message",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Pre-sign a message",
+                "parameters": [
+                    {
+                        "description": "Public key and key ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PreSignRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PreSignReponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tsm/reshareKey": {
+            "post": {
+                "description": "Rotate the key shares of player1, player2 and the mobile player, e.g. after a suspected device compromise. The public key and key ID stay the same; old shares, their backups and presignatures of the key become invalid.\nThe mobile player must join the session with the returned session ID. Poll /v1/tsm/sessions/{sessionId} for the result and retry a failed reshare until it succeeds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Reshare a key",
+                "parameters": [
+                    {
+                        "description": "Public key and key ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReshareKeyRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReshareKeyResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tsm/sessions/{sessionId}": {
+            "get": {
+                "description": "Get the result of a keygen, copy, reshare, import or presign session correlated over the players' completion callbacks",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Get a session result",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/sessiontracker.Session"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tsm/sessions/{sessionId}/cancel": {
+            "post": {
+                "description": "Cancel an abandoned keygen, copy, reshare, import or presign session on player1 and player2 so the nodes release it immediately",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Cancel a session on both players",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tsmcontroller.CancelSessionResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tsm/wrappingKeys": {
+            "get": {
+                "description": "Get the RSA keys the mobile client must wrap player1's and player2's shares with before /v1/tsm/importKey",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Get the wrapping keys of player1 and player2",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tsmcontroller.WrappingKeysResponseBody"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.BackupKeySharesRequestBody": {
+            "type": "object",
+            "required": [
+                "publicKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "publicKey": {
+                    "description": "base64 SubjectPublicKeyInfo of an operator RSA key (2048 bits or more)",
+                    "type": "string",
+                    "example": "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."
+                }
+            }
+        },
+        "handlers.CopyKeyRequestBody": {
             "type": "object",
             "required": [
                 "keyId",
                 "publicKey"
             ],
             "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "curve": {
+                    "description": "default curve of the algorithm if empty",
+                    "type": "string",
+                    "example": "ED-25519"
+                },
                 "keyId": {
                     "type": "string",
                     "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
@@ -291,23 +753,827 @@ const docTemplate = `{
                 "publicKey": {
                     "type": "string",
                     "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "threshold": {
+                    "description": "1 if empty",
+                    "type": "integer",
+                    "example": 1
+                },
+                "userId": {
+                    "description": "recorded in the key metadata",
+                    "type": "string",
+                    "example": "user-1234"
                 }
             }
         },
-        "main.RootResponse": {
+        "handlers.CopyResponseBody": {
             "type": "object",
+            "required": [
+                "sessionId"
+            ],
             "properties": {
-                "build_type": {
+                "sessionId": {
                     "type": "string"
+                }
+            }
+        },
+        "handlers.FindKeyMetadataResponseBody": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/keymetadata.KeyMetadata"
+                    }
+                }
+            }
+        },
+        "handlers.GenerateKeyRequestBody": {
+            "type": "object",
+            "required": [
+                "publicKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
                 },
-                "name": {
-                    "type": "string"
+                "curve": {
+                    "description": "default curve of the algorithm if empty",
+                    "type": "string",
+                    "example": "ED-25519"
                 },
-                "time": {
-                    "type": "string"
+                "publicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
                 },
-                "version": {
-                    "type": "string"
+                "threshold": {
+                    "description": "1 if empty",
+                    "type": "integer",
+                    "example": 1
+                },
+                "userId": {
+                    "description": "recorded in the key metadata",
+                    "type": "string",
+                    "example": "user-1234"
+                }
+            }
+        },
+        "handlers.GenerateKeyResponseBody": {
+            "type": "object",
+            "required": [
+                "sessionId"
+            ],
+            "properties": {
+                "sessionId": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ImportKeyRequestBody": {
+            "type": "object",
+            "required": [
+                "pkixPublicKey",
+                "player1",
+                "player2",
+                "publicKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "pkixPublicKey": {
+                    "description": "public key of the existing wallet. base64 SubjectPublicKeyInfo",
+                    "type": "string",
+                    "example": "MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="
+                },
+                "player1": {
+                    "$ref": "#/definitions/tsmcontroller.ImportShare"
+                },
+                "player2": {
+                    "$ref": "#/definitions/tsmcontroller.ImportShare"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "threshold": {
+                    "description": "1 if empty",
+                    "type": "integer",
+                    "example": 1
+                },
+                "userId": {
+                    "description": "recorded in the key metadata",
+                    "type": "string",
+                    "example": "user-1234"
+                }
+            }
+        },
+        "handlers.PartialSignBatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/tsmcontroller.PlayerErrorObject"
+                },
+                "partialSignResult": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                }
+            }
+        },
+        "handlers.PartialSignBatchRequestBody": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handlers.PartialSignRequestBody"
+                    }
+                }
+            }
+        },
+        "handlers.PartialSignBatchResponseBody": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "description": "same order as the request items",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.PartialSignBatchItemResult"
+                    }
+                }
+            }
+        },
+        "handlers.PartialSignRequestBody": {
+            "type": "object",
+            "required": [
+                "keyId",
+                "preSignatureId"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "derivationPath": {
+                    "description": "non-hardened. master key if empty",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        44,
+                        501,
+                        0
+                    ]
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "message": {
+                    "description": "base64 raw message. message mode, schnorr only",
+                    "type": "string",
+                    "example": "SGVsbG8sIHdvcmxkIQ=="
+                },
+                "messageHash": {
+                    "description": "base64. hash mode",
+                    "type": "string",
+                    "example": "MV9b23bQeMQ7isAGTkoBZGErH853yGk0W/yUx1iU7dM="
+                },
+                "mode": {
+                    "description": "hash (default) or message",
+                    "type": "string",
+                    "example": "hash"
+                },
+                "preSignatureId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                }
+            }
+        },
+        "handlers.PartialSignResponseBody": {
+            "type": "object",
+            "required": [
+                "partialSignResult"
+            ],
+            "properties": {
+                "partialSignResult": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                }
+            }
+        },
+        "handlers.PreSignReponseBody": {
+            "type": "object",
+            "required": [
+                "sessionId"
+            ],
+            "properties": {
+                "sessionId": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.PreSignRequestBody": {
+            "type": "object",
+            "required": [
+                "count",
+                "keyId",
+                "publicKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                }
+            }
+        },
+        "handlers.RecoveryDataRequestBody": {
+            "type": "object",
+            "required": [
+                "ersPublicKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "ersLabel": {
+                    "description": "OAEP label. optional",
+                    "type": "string",
+                    "example": "abc-tsm-recovery"
+                },
+                "ersPublicKey": {
+                    "description": "base64 SubjectPublicKeyInfo of the ERS RSA key",
+                    "type": "string",
+                    "example": "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."
+                }
+            }
+        },
+        "handlers.ReshareKeyRequestBody": {
+            "type": "object",
+            "required": [
+                "keyId",
+                "publicKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                }
+            }
+        },
+        "handlers.ReshareKeyResponseBody": {
+            "type": "object",
+            "properties": {
+                "keyId": {
+                    "description": "unchanged by resharing",
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                }
+            }
+        },
+        "handlers.RevokeKeyRequestBody": {
+            "type": "object",
+            "required": [
+                "devicePublicKey",
+                "reason",
+                "revokedBy"
+            ],
+            "properties": {
+                "devicePublicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "device lost"
+                },
+                "revokedBy": {
+                    "type": "string",
+                    "example": "support@ahnlab.io"
+                }
+            }
+        },
+        "keymetadata.KeyMetadata": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "curve": {
+                    "type": "string",
+                    "example": "ED-25519"
+                },
+                "devicePublicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "generateKey"
+                },
+                "publicKey": {
+                    "description": "base64 PKIX public key of the key",
+                    "type": "string",
+                    "example": "MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                },
+                "sourceKeyId": {
+                    "description": "the key a copied key was copied from",
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "user-1234"
+                }
+            }
+        },
+        "main.RootResponse": {
+            "type": "object",
+            "properties": {
+                "build_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "revocation.Revocation": {
+            "type": "object",
+            "properties": {
+                "devicePublicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "device lost"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "revokedBy": {
+                    "type": "string",
+                    "example": "support@ahnlab.io"
+                }
+            }
+        },
+        "sessiontracker.Event": {
+            "type": "object",
+            "required": [
+                "operation",
+                "playerIndex",
+                "sessionId",
+                "status"
+            ],
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "errorText": {
+                    "type": "string",
+                    "example": "SESSION_TIMEOUT"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "generateKey"
+                },
+                "playerIndex": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "presignatureIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "succeeded"
+                }
+            }
+        },
+        "sessiontracker.Session": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "generateKey"
+                },
+                "players": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/sessiontracker.Event"
+                    }
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "succeeded"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "tsmcontroller.BackupKeySharesResponseBody": {
+            "type": "object",
+            "properties": {
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "player1": {
+                    "$ref": "#/definitions/tsmcontroller.ShareBackup"
+                },
+                "player2": {
+                    "$ref": "#/definitions/tsmcontroller.ShareBackup"
+                }
+            }
+        },
+        "tsmcontroller.CancelSessionResponseBody": {
+            "type": "object",
+            "properties": {
+                "player1": {
+                    "description": "cancelled, finished or notFound",
+                    "type": "string",
+                    "example": "cancelled"
+                },
+                "player2": {
+                    "description": "cancelled, finished or notFound",
+                    "type": "string",
+                    "example": "cancelled"
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                }
+            }
+        },
+        "tsmcontroller.ImportShare": {
+            "type": "object",
+            "required": [
+                "wrappedKeyShare"
+            ],
+            "properties": {
+                "wrappedChainCode": {
+                    "type": "string",
+                    "example": "base64"
+                },
+                "wrappedKeyShare": {
+                    "type": "string",
+                    "example": "base64"
+                }
+            }
+        },
+        "tsmcontroller.KeyLineageItem": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "copies": {
+                    "description": "keyIds copied from this key",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                    ]
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "curve": {
+                    "type": "string",
+                    "example": "ED-25519"
+                },
+                "devicePublicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "generateKey"
+                },
+                "publicKey": {
+                    "description": "base64 PKIX public key of the key",
+                    "type": "string",
+                    "example": "MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="
+                },
+                "revocation": {
+                    "description": "set if the key has been revoked",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/revocation.Revocation"
+                        }
+                    ]
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                },
+                "sourceKeyId": {
+                    "description": "the key a copied key was copied from",
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "user-1234"
+                }
+            }
+        },
+        "tsmcontroller.KeyLineageResponseBody": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "description": "root first, then copies level by level",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tsmcontroller.KeyLineageItem"
+                    }
+                },
+                "rootKeyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "truncated": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "tsmcontroller.KeyListItem": {
+            "type": "object",
+            "properties": {
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "partial": {
+                    "description": "true if only one player has a share of the key",
+                    "type": "boolean",
+                    "example": false
+                },
+                "player1": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "player2": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "tsmcontroller.KeyListResponseBody": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tsmcontroller.KeyListItem"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "partialCount": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "tsmcontroller.PlayerErrorObject": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "rate limit exceeded"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "POLICY_DENIED"
+                }
+            }
+        },
+        "tsmcontroller.PublicKeyResponseBody": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "base58": {
+                    "type": "string",
+                    "example": "FQnyF8mUwgUapn3mjrcoCsURrmawEitKsMHkWfdYKtGP"
+                },
+                "base64": {
+                    "type": "string",
+                    "example": "1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="
+                },
+                "derivationPath": {
+                    "type": "string",
+                    "example": "m/44/501/0"
+                },
+                "hex": {
+                    "type": "string",
+                    "example": "d61bf425f83d54872146da73584ac7207735cfd1047fc77d9b8a10e86fcbc0e8"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "pkix": {
+                    "type": "string",
+                    "example": "MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="
+                }
+            }
+        },
+        "tsmcontroller.ShareBackup": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "RSA-OAEP-256+A256GCM"
+                },
+                "ciphertext": {
+                    "type": "string",
+                    "example": "base64"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "encryptedKey": {
+                    "type": "string",
+                    "example": "base64"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "nonce": {
+                    "type": "string",
+                    "example": "base64"
+                },
+                "playerIndex": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "recipientKeyId": {
+                    "type": "string",
+                    "example": "9f2c6a0de1b34c5a8f7e2d1c0b9a8f7e6d5c4b3a291817161514131211100f0e"
+                }
+            }
+        },
+        "tsmcontroller.WrappingKeysResponseBody": {
+            "type": "object",
+            "properties": {
+                "player1": {
+                    "description": "base64 SubjectPublicKeyInfo",
+                    "type": "string",
+                    "example": "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."
+                },
+                "player2": {
+                    "description": "base64 SubjectPublicKeyInfo",
+                    "type": "string",
+                    "example": "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."
+                }
+            }
+        },
+        "tsmutils.RecoveryPackage": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "ersLabel": {
+                    "type": "string",
+                    "example": "abc-tsm-recovery"
+                },
+                "ersPublicKey": {
+                    "description": "base64 SubjectPublicKeyInfo",
+                    "type": "string",
+                    "example": "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "publicKey": {
+                    "description": "base64 PKIX public key of the key",
+                    "type": "string",
+                    "example": "MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="
+                },
+                "recoveryData": {
+                    "description": "base64",
+                    "type": "string",
+                    "example": "eyJ..."
                 }
             }
         }
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ahnlabio/tsm-appserver/tsmcontroller"
+	"github.com/gin-gonic/gin"
+)
+
+type CommonErrorObject struct {
+	Text    string `json:"text" example:""`
+	Message string `json:"message" example:""`
+}
+
+func errResp(c *gin.Context, err error) {
+	if errorInfo, ok := err.(*tsmcontroller.SvcErr); ok {
+		res := CommonErrorObject{
+			Message: errorInfo.Msg,
+			Text:    errorInfo.Text,
+		}
+
+		status := errorInfo.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+
+		log.Printf("[ERROR] err: %s, url: %s, status: %d\n", err.Error(), c.Request.URL, status)
+		c.JSON(status, gin.H{"error": &res})
+		return
+	}
+	res := CommonErrorObject{
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": &res})
+}
@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 
+	"github.com/ahnlabio/tsm-appserver/auth"
+	"github.com/ahnlabio/tsm-appserver/keymetadata"
+	"github.com/ahnlabio/tsm-appserver/sessiontracker"
 	"github.com/ahnlabio/tsm-appserver/tsmcontroller"
+	"github.com/ahnlabio/tsm-appserver/tsmutils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,7 +25,11 @@ func NewHandler(t *tsmcontroller.TSMController) *Handlers {
 }
 
 type GenerateKeyRequestBody struct {
+	UserId    string `json:"userId" example:"user-1234"` // recorded in the key metadata
 	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	Algorithm string `json:"algorithm" example:"schnorr"` // schnorr (default) or ecdsa
+	Curve     string `json:"curve" example:"ED-25519"`    // default curve of the algorithm if empty
+	Threshold int    `json:"threshold" example:"1"`       // 1 if empty
 }
 
 type GenerateKeyResponseBody struct {
@@ -34,25 +44,40 @@ type GenerateKeyResponseBody struct {
 // @Produce json
 // @Param body body GenerateKeyRequestBody true "Public key"
 // @Success 200 {object} GenerateKeyResponseBody
-// @Router /v1/generateKey [post]
+// @Router /v1/tsm/generateKey [post]
 func (h *Handlers) GenerateKeyHandler(c *gin.Context) {
 	var requestBody GenerateKeyRequestBody
 	err := c.ShouldBind(&requestBody)
 	if err != nil {
 		log.Printf("[GenerateKeyHandler] c.ShouldBind Error: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errResp(c, tsmcontroller.InvalidInputError(err))
 		return
 	}
 
-	sessionId := h.TSMController.StartGenerateKeySession(requestBody.PublicKey)
+	if err := tsmutils.ValidatePlayerPublicKey(requestBody.PublicKey); err != nil {
+		log.Printf("[GenerateKeyHandler] invalid public key: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	sessionId, err := h.TSMController.StartGenerateKeySession(requestBody.UserId, requestBody.PublicKey, requestBody.Algorithm, requestBody.Curve, requestBody.Threshold)
+	if err != nil {
+		log.Printf("[GenerateKeyHandler] TSMController.StartGenerateKeySession Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
 	log.Printf("[GenerateKeyHandler] session id: %s", sessionId)
 
 	c.JSON(http.StatusOK, GenerateKeyResponseBody{SessionId: sessionId})
 }
 
 type CopyKeyRequestBody struct {
+	UserId    string `json:"userId" example:"user-1234"` // recorded in the key metadata
 	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
 	KeyId     string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm string `json:"algorithm" example:"schnorr"` // schnorr (default) or ecdsa
+	Curve     string `json:"curve" example:"ED-25519"`    // default curve of the algorithm if empty
+	Threshold int    `json:"threshold" example:"1"`       // 1 if empty
 }
 
 type CopyResponseBody struct {
@@ -67,26 +92,148 @@ type CopyResponseBody struct {
 // @Produce json
 // @Param body body CopyKeyRequestBody true "Public key and key ID"
 // @Success 200 {object} CopyResponseBody
-// @Router /copyKey [post]
+// @Router /v1/tsm/copyKey [post]
 func (h *Handlers) CopyKeyHandler(c *gin.Context) {
 	var requestBody CopyKeyRequestBody
 	err := c.ShouldBind(&requestBody)
 	if err != nil {
 		log.Printf("[CopyKeyHandler] c.ShouldBind Error: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	if err := tsmutils.ValidatePlayerPublicKey(requestBody.PublicKey); err != nil {
+		log.Printf("[CopyKeyHandler] invalid public key: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
 		return
 	}
 
-	sessionId := h.TSMController.StartCopyKeySession(requestBody.PublicKey, requestBody.KeyId)
+	sessionId, err := h.TSMController.StartCopyKeySession(requestBody.UserId, requestBody.PublicKey, requestBody.KeyId, requestBody.Algorithm, requestBody.Curve, requestBody.Threshold)
+	if err != nil {
+		log.Printf("[CopyKeyHandler] TSMController.StartCopyKeySession Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
 	log.Printf("[CopyKeyHandler] session id: %s", sessionId)
 
 	c.JSON(http.StatusOK, GenerateKeyResponseBody{SessionId: sessionId})
 }
 
+// WrappingKeysHandler godoc
+// @Summary Get the wrapping keys of player1 and player2
+// @Description Get the RSA keys the mobile client must wrap player1's and player2's shares with before /v1/tsm/importKey
+// @Tags key
+// @Produce json
+// @Success 200 {object} tsmcontroller.WrappingKeysResponseBody
+// @Router /v1/tsm/wrappingKeys [get]
+func (h *Handlers) WrappingKeysHandler(c *gin.Context) {
+	wrappingKeys, err := h.TSMController.WrappingKeys()
+	if err != nil {
+		log.Printf("[WrappingKeysHandler] TSMController.WrappingKeys Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, wrappingKeys)
+}
+
+type ImportKeyRequestBody struct {
+	UserId        string                    `json:"userId" example:"user-1234"` // recorded in the key metadata
+	PublicKey     string                    `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	PKIXPublicKey string                    `json:"pkixPublicKey" binding:"required" example:"MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="` // public key of the existing wallet. base64 SubjectPublicKeyInfo
+	Algorithm     string                    `json:"algorithm" example:"schnorr"`                                                                             // schnorr (default) or ecdsa
+	Threshold     int                       `json:"threshold" example:"1"`                                                                                   // 1 if empty
+	Player1       tsmcontroller.ImportShare `json:"player1" binding:"required"`
+	Player2       tsmcontroller.ImportShare `json:"player2" binding:"required"`
+}
+
+// ImportKeyHandler godoc
+// @Summary Import an existing key
+// @Description Start an import ceremony for an existing private key, e.g. an Ed25519 wallet. The mobile client splits the key into player 0/1/2 shares (tsmutils.ShamirSecretShare), wraps player1's and player2's shares with the keys from /v1/tsm/wrappingKeys (tsmutils.Wrap) and sends them here.
+// @Description Then it joins the session with ImportKeyShares and its own share. The session fails unless the shares form the key of pkixPublicKey, so the resulting keyId has the public key of the imported wallet.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Param body body ImportKeyRequestBody true "Wrapped key shares and public key"
+// @Success 200 {object} GenerateKeyResponseBody
+// @Router /v1/tsm/importKey [post]
+func (h *Handlers) ImportKeyHandler(c *gin.Context) {
+	var requestBody ImportKeyRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[ImportKeyHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	if err := tsmutils.ValidatePlayerPublicKey(requestBody.PublicKey); err != nil {
+		log.Printf("[ImportKeyHandler] invalid public key: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	sessionId, err := h.TSMController.StartImportKeySession(requestBody.UserId, requestBody.PublicKey, requestBody.PKIXPublicKey, requestBody.Algorithm, requestBody.Threshold, requestBody.Player1, requestBody.Player2)
+	if err != nil {
+		log.Printf("[ImportKeyHandler] TSMController.StartImportKeySession Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	log.Printf("[ImportKeyHandler] session id: %s", sessionId)
+
+	c.JSON(http.StatusOK, GenerateKeyResponseBody{SessionId: sessionId})
+}
+
+type ReshareKeyRequestBody struct {
+	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	KeyId     string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm string `json:"algorithm" example:"schnorr"` // schnorr (default) or ecdsa
+}
+
+type ReshareKeyResponseBody struct {
+	SessionId string `json:"sessionId" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	KeyId     string `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"` // unchanged by resharing
+}
+
+// ReshareKeyHandler godoc
+// @Summary Reshare a key
+// @Description Rotate the key shares of player1, player2 and the mobile player, e.g. after a suspected device compromise. The public key and key ID stay the same; old shares, their backups and presignatures of the key become invalid.
+// @Description The mobile player must join the session with the returned session ID. Poll /v1/tsm/sessions/{sessionId} for the result and retry a failed reshare until it succeeds.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Param body body ReshareKeyRequestBody true "Public key and key ID"
+// @Success 200 {object} ReshareKeyResponseBody
+// @Router /v1/tsm/reshareKey [post]
+func (h *Handlers) ReshareKeyHandler(c *gin.Context) {
+	var requestBody ReshareKeyRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[ReshareKeyHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	if err := tsmutils.ValidatePlayerPublicKey(requestBody.PublicKey); err != nil {
+		log.Printf("[ReshareKeyHandler] invalid public key: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	sessionId, err := h.TSMController.StartReshareSession(requestBody.PublicKey, requestBody.KeyId, requestBody.Algorithm)
+	if err != nil {
+		log.Printf("[ReshareKeyHandler] TSMController.StartReshareSession Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	log.Printf("[ReshareKeyHandler] session id: %s", sessionId)
+
+	c.JSON(http.StatusOK, ReshareKeyResponseBody{SessionId: sessionId, KeyId: requestBody.KeyId})
+}
+
 type PreSignRequestBody struct {
 	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
 	KeyId     string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
 	Count     uint64 `json:"count" binding:"required" example:"3"`
+	Algorithm string `json:"algorithm" example:"schnorr"` // schnorr (default) or ecdsa
 }
 
 type PreSignReponseBody struct {
@@ -101,26 +248,41 @@ type PreSignReponseBody struct {
 // @Produce json
 // @Param body body PreSignRequestBody true "Public key and key ID"
 // @Success 200 {object} PreSignReponseBody
-// @Router /preSign [post]
+// @Router /v1/tsm/preSign [post]
 func (h *Handlers) PreSignHandler(c *gin.Context) {
 	var requestBody PreSignRequestBody
 	err := c.ShouldBind(&requestBody)
 	if err != nil {
 		log.Printf("[PreSignHandler] c.ShouldBind Error: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	if err := tsmutils.ValidatePlayerPublicKey(requestBody.PublicKey); err != nil {
+		log.Printf("[PreSignHandler] invalid public key: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
 		return
 	}
 
-	sessionId := h.TSMController.StartPresignSession(requestBody.PublicKey, requestBody.KeyId, requestBody.Count)
+	sessionId, err := h.TSMController.StartPresignSession(requestBody.PublicKey, requestBody.KeyId, requestBody.Count, requestBody.Algorithm)
+	if err != nil {
+		log.Printf("[PreSignHandler] TSMController.StartPresignSession Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
 	log.Printf("[PreSignHandler] session id: %s", sessionId)
 
 	c.JSON(http.StatusOK, GenerateKeyResponseBody{SessionId: sessionId})
 }
 
 type PartialSignRequestBody struct {
-	PreSignatureId string `json:"preSignatureId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
-	MessageHash    string `json:"messageHash" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
-	KeyId          string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	PreSignatureId string   `json:"preSignatureId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Mode           string   `json:"mode" example:"hash"`                                                // hash (default) or message
+	MessageHash    string   `json:"messageHash" example:"MV9b23bQeMQ7isAGTkoBZGErH853yGk0W/yUx1iU7dM="` // base64. hash mode
+	Message        string   `json:"message" example:"SGVsbG8sIHdvcmxkIQ=="`                             // base64 raw message. message mode, schnorr only
+	KeyId          string   `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm      string   `json:"algorithm" example:"schnorr"`       // schnorr (default) or ecdsa
+	DerivationPath []uint32 `json:"derivationPath" example:"44,501,0"` // non-hardened. master key if empty
 }
 
 type PartialSignResponseBody struct {
@@ -133,25 +295,390 @@ type PartialSignResponseBody struct {
 // @Tags session
 // @Accept json
 // @Produce json
-// @Param body body FinalizSignRequestBody true "Pre-signature ID, message hash, and key ID"
-// @Success 200 {object} FinalizeSignResponseBody
-// @Router /finalizeSign [post]
+// @Param body body PartialSignRequestBody true "Pre-signature ID, message hash, and key ID"
+// @Success 200 {object} PartialSignResponseBody
+// @Router /v1/tsm/finalizeSign [post]
 func (h *Handlers) PartialSignHandler(c *gin.Context) {
 	var requestBody PartialSignRequestBody
 	err := c.ShouldBind(&requestBody)
 	if err != nil {
 		log.Printf("[PartialSignHandler] c.ShouldBind Error: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errResp(c, tsmcontroller.InvalidInputError(err))
 		return
 	}
 
-	signature, err := h.TSMController.PartialSign(requestBody.PreSignatureId, requestBody.MessageHash, requestBody.KeyId)
+	signature, err := h.TSMController.PartialSign(requestBody.PreSignatureId, requestBody.Mode, requestBody.MessageHash, requestBody.Message, requestBody.KeyId, requestBody.Algorithm, requestBody.DerivationPath)
 	if err != nil {
-		log.Printf("[PartialSignHandler] service.GenerateKey Error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("[PartialSignHandler] TSMController.PartialSign Error: %v\n", err)
+		errResp(c, err)
 		return
 	}
 
 	log.Printf("[PartialSignHandler] partialSignResult: %v", signature)
 	c.JSON(http.StatusOK, PartialSignResponseBody{PartialSignature: signature})
 }
+
+type PartialSignBatchRequestBody struct {
+	Items []PartialSignRequestBody `json:"items" binding:"required,min=1,max=100,dive"`
+}
+
+type PartialSignBatchItemResult struct {
+	PartialSignature string                           `json:"partialSignResult,omitempty" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Error            *tsmcontroller.PlayerErrorObject `json:"error,omitempty"`
+}
+
+type PartialSignBatchResponseBody struct {
+	Results []PartialSignBatchItemResult `json:"results"` // same order as the request items
+}
+
+// PartialSignBatchHandler godoc
+// @Summary Finalize signatures of a batch of messages
+// @Description Get player partial signatures for up to 100 (preSignatureId, messageHash, keyId) items, e.g. a multi-instruction transaction bundle, in one request.
+// @Description Items are independent: a failed item does not stop or roll back the others and carries its error (text and message as in /finalizeSign) instead of partialSignResult.
+// @Description The whole request fails only if the body or an item is malformed (400) or the player cannot be reached (503).
+// @Tags session
+// @Accept json
+// @Produce json
+// @Param body body PartialSignBatchRequestBody true "Sign items"
+// @Success 200 {object} PartialSignBatchResponseBody
+// @Router /v1/tsm/finalizeSignBatch [post]
+func (h *Handlers) PartialSignBatchHandler(c *gin.Context) {
+	var requestBody PartialSignBatchRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[PartialSignBatchHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	items := make([]tsmcontroller.PartialSignRequestBody, len(requestBody.Items))
+	for i, item := range requestBody.Items {
+		items[i] = tsmcontroller.PartialSignRequestBody{
+			SignSignatureId: item.PreSignatureId,
+			Mode:            item.Mode,
+			MessageHash:     item.MessageHash,
+			Message:         item.Message,
+			KeyId:           item.KeyId,
+			Algorithm:       item.Algorithm,
+			DerivationPath:  item.DerivationPath,
+		}
+	}
+
+	results, err := h.TSMController.PartialSignBatch(items)
+	if err != nil {
+		log.Printf("[PartialSignBatchHandler] TSMController.PartialSignBatch Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+
+	responseBody := PartialSignBatchResponseBody{Results: make([]PartialSignBatchItemResult, len(results))}
+	for i, result := range results {
+		responseBody.Results[i] = PartialSignBatchItemResult{PartialSignature: result.Signature, Error: result.Error}
+	}
+	c.JSON(http.StatusOK, responseBody)
+}
+
+// PublicKeyHandler godoc
+// @Summary Get the public key of a key
+// @Description Get the public key of a key, optionally derived with a non-hardened BIP32 path. hex, base64 and base58 are encodings of the raw public key.
+// @Tags key
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Param algorithm query string false "schnorr (default) or ecdsa"
+// @Param derivationPath query string false "non-hardened derivation path. e.g. m/44/501/0"
+// @Success 200 {object} tsmcontroller.PublicKeyResponseBody
+// @Router /v1/tsm/keys/{keyId}/publicKey [get]
+func (h *Handlers) PublicKeyHandler(c *gin.Context) {
+	keyId := c.Param("keyId")
+	algorithm := c.Query("algorithm")
+	derivationPath := c.Query("derivationPath")
+
+	publicKey, err := h.TSMController.PublicKey(keyId, algorithm, derivationPath)
+	if err != nil {
+		log.Printf("[PublicKeyHandler] TSMController.PublicKey Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, publicKey)
+}
+
+// ListKeysHandler godoc
+// @Summary List keys of both players
+// @Description List keys held by player 1 and player 2. keys that exist on only one player are flagged as partial.
+// @Tags key
+// @Produce json
+// @Param offset query int false "offset" default(0)
+// @Param limit query int false "limit. max 1000" default(100)
+// @Success 200 {object} tsmcontroller.KeyListResponseBody
+// @Router /v1/tsm/keys [get]
+func (h *Handlers) ListKeysHandler(c *gin.Context) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	keyList, err := h.TSMController.ListKeys(offset, limit)
+	if err != nil {
+		log.Printf("[ListKeysHandler] TSMController.ListKeys Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keyList)
+}
+
+type BackupKeySharesRequestBody struct {
+	PublicKey string `json:"publicKey" binding:"required" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."` // base64 SubjectPublicKeyInfo of an operator RSA key (2048 bits or more)
+	Algorithm string `json:"algorithm" example:"schnorr"`                                                            // schnorr (default) or ecdsa
+}
+
+// BackupKeySharesHandler godoc
+// @Summary Back up the server shares of a key
+// @Description Export player1's and player2's shares of a key, each encrypted to an operator RSA public key for disaster recovery. The key must be allowed by BACKUP_RECIPIENT_FINGERPRINTS on both players. Fails if either backup fails.
+// @Tags key
+// @Accept json
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Param body body BackupKeySharesRequestBody true "Operator public key"
+// @Success 200 {object} tsmcontroller.BackupKeySharesResponseBody
+// @Router /v1/tsm/keys/{keyId}/backup [post]
+func (h *Handlers) BackupKeySharesHandler(c *gin.Context) {
+	var requestBody BackupKeySharesRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[BackupKeySharesHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	backups, err := h.TSMController.BackupKeyShares(c.Param("keyId"), requestBody.Algorithm, requestBody.PublicKey)
+	if err != nil {
+		log.Printf("[BackupKeySharesHandler] TSMController.BackupKeyShares Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, backups)
+}
+
+type RecoveryDataRequestBody struct {
+	ERSPublicKey string `json:"ersPublicKey" binding:"required" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."` // base64 SubjectPublicKeyInfo of the ERS RSA key
+	ERSLabel     string `json:"ersLabel" example:"abc-tsm-recovery"`                                                       // OAEP label. optional
+	Algorithm    string `json:"algorithm" example:"schnorr"`                                                               // schnorr (default) or ecdsa
+}
+
+// RecoveryDataHandler godoc
+// @Summary Export ERS recovery data of a key
+// @Description Generate Emergency Recovery System data for a key from player1's and player2's shares, encrypted to an ERS RSA public key allowed by ERS_RECIPIENT_FINGERPRINTS on both players.
+// @Description The recovery data is validated against the key's public key before it is returned. Save the response and check it offline with cmd/ersverify. The holder of the ERS private key can recover the private key without the service.
+// @Tags key
+// @Accept json
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Param body body RecoveryDataRequestBody true "ERS public key and label"
+// @Success 200 {object} tsmutils.RecoveryPackage
+// @Router /v1/tsm/keys/{keyId}/recoveryData [post]
+func (h *Handlers) RecoveryDataHandler(c *gin.Context) {
+	var requestBody RecoveryDataRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[RecoveryDataHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	recoveryPackage, err := h.TSMController.ExportRecoveryData(c.Param("keyId"), requestBody.Algorithm, requestBody.ERSPublicKey, requestBody.ERSLabel)
+	if err != nil {
+		log.Printf("[RecoveryDataHandler] TSMController.ExportRecoveryData Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, recoveryPackage)
+}
+
+type RevokeKeyRequestBody struct {
+	RevokedBy       string `json:"revokedBy" binding:"required" example:"support@ahnlab.io"`
+	Reason          string `json:"reason" binding:"required" example:"device lost"`
+	DevicePublicKey string `json:"devicePublicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+}
+
+// RevokeKeyHandler godoc
+// @Summary Revoke a device key
+// @Description Delete the server side key shares of a device copy on player 1 and player 2 and record who revoked it and why
+// @Description The key must be recorded in key metadata as a copy made for devicePublicKey. Original keys and keys of other devices can't be revoked.
+// @Description Key metadata is recorded from the session callbacks of the players, so the controllers must be configured with CALLBACK_URL.
+// @Tags key
+// @Accept json
+// @Produce json
+// @Param keyId path string true "Key ID of the device copy"
+// @Param body body RevokeKeyRequestBody true "Revoker and reason"
+// @Success 200 {object} revocation.Revocation
+// @Router /v1/tsm/keys/{keyId}/revoke [post]
+func (h *Handlers) RevokeKeyHandler(c *gin.Context) {
+	var requestBody RevokeKeyRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[RevokeKeyHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	record, err := h.TSMController.RevokeKey(c.Param("keyId"), requestBody.DevicePublicKey, requestBody.RevokedBy, requestBody.Reason)
+	if err != nil {
+		log.Printf("[RevokeKeyHandler] TSMController.RevokeKey Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// GetRevocationHandler godoc
+// @Summary Get the revocation record of a key
+// @Description Get who revoked a key and why
+// @Tags key
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Success 200 {object} revocation.Revocation
+// @Router /v1/tsm/keys/{keyId}/revocation [get]
+func (h *Handlers) GetRevocationHandler(c *gin.Context) {
+	record, err := h.TSMController.GetRevocation(c.Param("keyId"))
+	if err != nil {
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// GetKeyMetadataHandler godoc
+// @Summary Get the metadata of a key
+// @Description Get the user, device public key, curve, creation time and source session of a key created through the appserver
+// @Tags key
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Success 200 {object} keymetadata.KeyMetadata
+// @Router /v1/tsm/keys/{keyId}/metadata [get]
+func (h *Handlers) GetKeyMetadataHandler(c *gin.Context) {
+	metadata, err := h.TSMController.GetKeyMetadata(c.Param("keyId"))
+	if err != nil {
+		log.Printf("[GetKeyMetadataHandler] TSMController.GetKeyMetadata Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, metadata)
+}
+
+// KeyLineageHandler godoc
+// @Summary Get the lineage of a key
+// @Description Get the original key of a key and every key copied from it, with their device public keys and revocations. Keys created before key metadata was recorded only have keyId.
+// @Description Key metadata is recorded from the session callbacks of the players, so the controllers must be configured with CALLBACK_URL.
+// @Tags key
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Success 200 {object} tsmcontroller.KeyLineageResponseBody
+// @Router /v1/tsm/keys/{keyId}/lineage [get]
+func (h *Handlers) KeyLineageHandler(c *gin.Context) {
+	lineage, err := h.TSMController.KeyLineage(c.Param("keyId"))
+	if err != nil {
+		log.Printf("[KeyLineageHandler] TSMController.KeyLineage Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, lineage)
+}
+
+type FindKeyMetadataResponseBody struct {
+	Keys []keymetadata.KeyMetadata `json:"keys"`
+}
+
+// FindKeyMetadataHandler godoc
+// @Summary Find the keys of a user or device
+// @Description List the metadata of the keys of a user and/or device public key, oldest first. At least one of userId and devicePublicKey is required. devicePublicKey must be URL encoded.
+// @Description Key metadata is recorded from the session callbacks of the players, so the controllers must be configured with CALLBACK_URL.
+// @Tags key
+// @Produce json
+// @Param userId query string false "User ID"
+// @Param devicePublicKey query string false "Device (player 0) public key. base64"
+// @Success 200 {object} FindKeyMetadataResponseBody
+// @Router /v1/tsm/keyMetadata [get]
+func (h *Handlers) FindKeyMetadataHandler(c *gin.Context) {
+	keys, err := h.TSMController.FindKeyMetadata(c.Query("userId"), c.Query("devicePublicKey"))
+	if err != nil {
+		log.Printf("[FindKeyMetadataHandler] TSMController.FindKeyMetadata Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, FindKeyMetadataResponseBody{Keys: keys})
+}
+
+// CancelSessionHandler godoc
+// @Summary Cancel a session on both players
+// @Description Cancel an abandoned keygen, copy, reshare, import or presign session on player1 and player2 so the nodes release it immediately
+// @Tags session
+// @Produce json
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} tsmcontroller.CancelSessionResponseBody
+// @Router /v1/tsm/sessions/{sessionId}/cancel [post]
+func (h *Handlers) CancelSessionHandler(c *gin.Context) {
+	result, err := h.TSMController.CancelSession(c.Param("sessionId"))
+	if err != nil {
+		log.Printf("[CancelSessionHandler] TSMController.CancelSession Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetSessionHandler godoc
+// @Summary Get a session result
+// @Description Get the result of a keygen, copy, reshare, import or presign session correlated over the players' completion callbacks
+// @Tags session
+// @Produce json
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} sessiontracker.Session
+// @Router /v1/tsm/sessions/{sessionId} [get]
+func (h *Handlers) GetSessionHandler(c *gin.Context) {
+	result, err := h.TSMController.GetSession(c.Param("sessionId"))
+	if err != nil {
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// SessionCallbackHandler godoc
+// @Summary Receive a session completion event
+// @Description Called by player1 and player2 when their part of a session succeeds or fails. The request must be HMAC signed with the callback key of the player in playerIndex.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Param body body sessiontracker.Event true "Completion event"
+// @Success 200 {object} sessiontracker.Session
+// @Router /v1/tsm/callbacks/sessions [post]
+func (h *Handlers) SessionCallbackHandler(c *gin.Context) {
+	var requestBody sessiontracker.Event
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[SessionCallbackHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, tsmcontroller.InvalidInputError(err))
+		return
+	}
+
+	// 다른 player 의 결과를 보고할 수 없도록 서명한 player 와 event 의 player 가 같아야 합니다.
+	if signer := auth.Identity(c); signer != requestBody.PlayerIndex {
+		errResp(c, tsmcontroller.ForbiddenError(fmt.Errorf("callback signed by player%s can't report playerIndex %s", signer, requestBody.PlayerIndex)))
+		return
+	}
+
+	result, err := h.TSMController.RecordSessionEvent(requestBody)
+	if err != nil {
+		log.Printf("[SessionCallbackHandler] TSMController.RecordSessionEvent Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
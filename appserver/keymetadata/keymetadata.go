@@ -0,0 +1,130 @@
+package keymetadata
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// KeyMetadata records who and which session produced a key.
+type KeyMetadata struct {
+	KeyId           string    `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	UserId          string    `json:"userId,omitempty" example:"user-1234"`
+	DevicePublicKey string    `json:"devicePublicKey" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	Algorithm       string    `json:"algorithm" example:"schnorr"`
+	Curve           string    `json:"curve" example:"ED-25519"`
+	PublicKey       string    `json:"publicKey,omitempty" example:"MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="` // base64 PKIX public key of the key
+	Operation       string    `json:"operation" example:"generateKey"`
+	SessionId       string    `json:"sessionId" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	SourceKeyId     string    `json:"sourceKeyId,omitempty" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"` // the key a copied key was copied from
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// Query selects keys by user, device and/or source key. Empty fields match any value.
+type Query struct {
+	UserId          string
+	DevicePublicKey string
+	SourceKeyId     string
+}
+
+// Store keeps the metadata of keys created through the appserver.
+type Store interface {
+	Save(metadata KeyMetadata) error
+	Get(keyId string) (*KeyMetadata, bool, error)
+	// Find returns the keys matching the query, oldest first.
+	Find(query Query) ([]KeyMetadata, error)
+}
+
+type MemoryStore struct {
+	mu   sync.RWMutex
+	keys map[string]KeyMetadata
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]KeyMetadata)}
+}
+
+func (m *MemoryStore) Save(metadata KeyMetadata) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.keys[metadata.KeyId] = metadata
+	return nil
+}
+
+func (m *MemoryStore) Get(keyId string) (*KeyMetadata, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	metadata, ok := m.keys[keyId]
+	if !ok {
+		return nil, false, nil
+	}
+	return &metadata, true, nil
+}
+
+func (m *MemoryStore) Find(query Query) ([]KeyMetadata, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := []KeyMetadata{}
+	for _, metadata := range m.keys {
+		if query.UserId != "" && metadata.UserId != query.UserId {
+			continue
+		}
+		if query.DevicePublicKey != "" && metadata.DevicePublicKey != query.DevicePublicKey {
+			continue
+		}
+		if query.SourceKeyId != "" && metadata.SourceKeyId != query.SourceKeyId {
+			continue
+		}
+		result = append(result, metadata)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].KeyId < result[j].KeyId
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// pending sessions are dropped after this long. a session that never finishes leaves no metadata.
+const pendingRetention = 24 * time.Hour
+
+// Pending holds the metadata of sessions that have been started but not finished yet.
+// The keyId is only known when the players report the result, so the metadata is saved then.
+type Pending struct {
+	mu       sync.Mutex
+	sessions map[string]KeyMetadata
+}
+
+func NewPending() *Pending {
+	return &Pending{sessions: make(map[string]KeyMetadata)}
+}
+
+// Add remembers the metadata of a started session. CreatedAt is used as the start time until the key is saved.
+func (p *Pending) Add(metadata KeyMetadata) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cutoff := time.Now().Add(-pendingRetention)
+	for sessionId, pending := range p.sessions {
+		if pending.CreatedAt.Before(cutoff) {
+			delete(p.sessions, sessionId)
+		}
+	}
+	p.sessions[metadata.SessionId] = metadata
+}
+
+// Take removes and returns the metadata of a session.
+func (p *Pending) Take(sessionId string) (KeyMetadata, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	metadata, ok := p.sessions[sessionId]
+	if ok {
+		delete(p.sessions, sessionId)
+	}
+	return metadata, ok
+}
@@ -0,0 +1,86 @@
+package keymetadata
+
+import "fmt"
+
+// a lineage larger than this is cut off. a user has a few devices, so it is not expected in practice.
+const MAX_LINEAGE_SIZE int = 1000
+
+// LineageNode is a key in a lineage. Only KeyId is set if the key was created before key metadata was recorded.
+type LineageNode struct {
+	KeyMetadata
+	Copies []string `json:"copies" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"` // keyIds copied from this key
+}
+
+// Lineage is the original key and every key copied from it, directly or from another copy.
+type Lineage struct {
+	RootKeyId string        `json:"rootKeyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Keys      []LineageNode `json:"keys"` // root first, then copies level by level
+	Truncated bool          `json:"truncated" example:"false"`
+}
+
+// FindLineage walks from keyId up to the original key and collects all copies of it.
+// found is false if nothing is recorded about keyId.
+func FindLineage(store Store, keyId string) (lineage *Lineage, found bool, err error) {
+	metadata, ok, err := store.Get(keyId)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		copies, err := store.Find(Query{SourceKeyId: keyId})
+		if err != nil {
+			return nil, false, err
+		}
+		if len(copies) == 0 {
+			return nil, false, nil
+		}
+	}
+
+	// 원본 key 까지 올라갑니다. metadata 가 없는 key 는 기록 이전에 만들어진 원본으로 봅니다.
+	rootKeyId := keyId
+	visited := map[string]bool{keyId: true}
+	for ok && metadata.SourceKeyId != "" {
+		if visited[metadata.SourceKeyId] {
+			return nil, false, fmt.Errorf("key lineage has a cycle at %s", metadata.SourceKeyId)
+		}
+		rootKeyId = metadata.SourceKeyId
+		visited[rootKeyId] = true
+		if metadata, ok, err = store.Get(rootKeyId); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// 원본에서 복사본을 단계별로 찾습니다.
+	lineage = &Lineage{RootKeyId: rootKeyId}
+	visited = map[string]bool{rootKeyId: true}
+	queue := []string{rootKeyId}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		node := LineageNode{KeyMetadata: KeyMetadata{KeyId: current}, Copies: []string{}}
+		if metadata, ok, err := store.Get(current); err != nil {
+			return nil, false, err
+		} else if ok {
+			node.KeyMetadata = *metadata
+		}
+
+		copies, err := store.Find(Query{SourceKeyId: current})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, copied := range copies {
+			if visited[copied.KeyId] {
+				continue
+			}
+			if len(visited) >= MAX_LINEAGE_SIZE {
+				lineage.Truncated = true
+				break
+			}
+			visited[copied.KeyId] = true
+			node.Copies = append(node.Copies, copied.KeyId)
+			queue = append(queue, copied.KeyId)
+		}
+		lineage.Keys = append(lineage.Keys, node)
+	}
+	return lineage, true, nil
+}
@@ -0,0 +1,182 @@
+package keymetadata
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	POSTGRES string = "postgres"
+	SQLITE   string = "sqlite3"
+)
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS key_metadata (
+		key_id            VARCHAR(255) PRIMARY KEY,
+		user_id           VARCHAR(255) NOT NULL DEFAULT '',
+		device_public_key TEXT NOT NULL,
+		algorithm         VARCHAR(32) NOT NULL,
+		curve             VARCHAR(32) NOT NULL,
+		public_key        TEXT NOT NULL DEFAULT '',
+		operation         VARCHAR(32) NOT NULL,
+		session_id        VARCHAR(255) NOT NULL,
+		source_key_id     VARCHAR(255) NOT NULL DEFAULT '',
+		created_at        TIMESTAMP NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS key_metadata_user_id ON key_metadata (user_id)`,
+	`CREATE INDEX IF NOT EXISTS key_metadata_device_public_key ON key_metadata (device_public_key)`,
+	`CREATE INDEX IF NOT EXISTS key_metadata_source_key_id ON key_metadata (source_key_id)`,
+}
+
+const columns = "key_id, user_id, device_public_key, algorithm, curve, public_key, operation, session_id, source_key_id, created_at"
+
+// SQLStore keeps key metadata in SQLite or PostgreSQL.
+type SQLStore struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewSQLStore opens the database and creates the key_metadata table if it doesn't exist.
+func NewSQLStore(driver string, dsn string) (*SQLStore, error) {
+	if driver != POSTGRES && driver != SQLITE {
+		return nil, fmt.Errorf("unsupported key metadata driver: %s", driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == SQLITE {
+		// sqlite 는 동시에 하나의 writer 만 허용하므로 connection 을 하나만 사용합니다.
+		db.SetMaxOpenConns(1)
+	}
+	for _, statement := range schema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create key_metadata table: %w", err)
+		}
+	}
+	return &SQLStore{db: db, driver: driver}, nil
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) Save(metadata KeyMetadata) error {
+	query := s.rebind(`INSERT INTO key_metadata (` + columns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (key_id) DO UPDATE SET
+			user_id = excluded.user_id,
+			device_public_key = excluded.device_public_key,
+			algorithm = excluded.algorithm,
+			curve = excluded.curve,
+			public_key = excluded.public_key,
+			operation = excluded.operation,
+			session_id = excluded.session_id,
+			source_key_id = excluded.source_key_id,
+			created_at = excluded.created_at`)
+	_, err := s.db.Exec(query,
+		metadata.KeyId,
+		metadata.UserId,
+		metadata.DevicePublicKey,
+		metadata.Algorithm,
+		metadata.Curve,
+		metadata.PublicKey,
+		metadata.Operation,
+		metadata.SessionId,
+		metadata.SourceKeyId,
+		metadata.CreatedAt.UTC(),
+	)
+	return err
+}
+
+func (s *SQLStore) Get(keyId string) (*KeyMetadata, bool, error) {
+	rows, err := s.db.Query(s.rebind(`SELECT `+columns+` FROM key_metadata WHERE key_id = ?`), keyId)
+	if err != nil {
+		return nil, false, err
+	}
+	result, err := scan(rows)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(result) == 0 {
+		return nil, false, nil
+	}
+	return &result[0], true, nil
+}
+
+func (s *SQLStore) Find(query Query) ([]KeyMetadata, error) {
+	var conditions []string
+	var args []any
+	if query.UserId != "" {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, query.UserId)
+	}
+	if query.DevicePublicKey != "" {
+		conditions = append(conditions, "device_public_key = ?")
+		args = append(args, query.DevicePublicKey)
+	}
+	if query.SourceKeyId != "" {
+		conditions = append(conditions, "source_key_id = ?")
+		args = append(args, query.SourceKeyId)
+	}
+	statement := `SELECT ` + columns + ` FROM key_metadata`
+	if len(conditions) > 0 {
+		statement += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	statement += ` ORDER BY created_at, key_id`
+
+	rows, err := s.db.Query(s.rebind(statement), args...)
+	if err != nil {
+		return nil, err
+	}
+	return scan(rows)
+}
+
+// rebind 는 postgres 에서 ? placeholder 를 $1, $2 ... 로 바꿉니다.
+func (s *SQLStore) rebind(query string) string {
+	if s.driver != POSTGRES {
+		return query
+	}
+	var builder strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&builder, "$%d", n)
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+func scan(rows *sql.Rows) ([]KeyMetadata, error) {
+	defer rows.Close()
+
+	result := []KeyMetadata{}
+	for rows.Next() {
+		var metadata KeyMetadata
+		err := rows.Scan(
+			&metadata.KeyId,
+			&metadata.UserId,
+			&metadata.DevicePublicKey,
+			&metadata.Algorithm,
+			&metadata.Curve,
+			&metadata.PublicKey,
+			&metadata.Operation,
+			&metadata.SessionId,
+			&metadata.SourceKeyId,
+			&metadata.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		metadata.CreatedAt = metadata.CreatedAt.UTC()
+		result = append(result, metadata)
+	}
+	return result, rows.Err()
+}
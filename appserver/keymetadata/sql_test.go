@@ -0,0 +1,96 @@
+package keymetadata
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	query := `SELECT key_id FROM key_metadata WHERE user_id = ? AND source_key_id = ?`
+
+	postgres := &SQLStore{driver: POSTGRES}
+	if got, want := postgres.rebind(query), `SELECT key_id FROM key_metadata WHERE user_id = $1 AND source_key_id = $2`; got != want {
+		t.Errorf("postgres rebind = %s, want %s", got, want)
+	}
+	sqlite := &SQLStore{driver: SQLITE}
+	if got := sqlite.rebind(query); got != query {
+		t.Errorf("sqlite rebind = %s, want the query unchanged", got)
+	}
+	if got := postgres.rebind(`SELECT 1`); got != `SELECT 1` {
+		t.Errorf("rebind without placeholders = %s", got)
+	}
+}
+
+func TestNewSQLStoreRejectsUnknownDriver(t *testing.T) {
+	if _, err := NewSQLStore("mysql", ""); err == nil {
+		t.Error("expected an error for an unsupported driver")
+	}
+}
+
+func TestSQLStore(t *testing.T) {
+	store, err := NewSQLStore(SQLITE, "file:"+filepath.Join(t.TempDir(), "keymetadata.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	createdAt := time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+	original := KeyMetadata{
+		KeyId:           "original",
+		UserId:          "user",
+		DevicePublicKey: "device1",
+		Algorithm:       "schnorr",
+		Curve:           "ED-25519",
+		Operation:       "generateKey",
+		SessionId:       "s1",
+		CreatedAt:       createdAt,
+	}
+	copied := original
+	copied.KeyId = "copy"
+	copied.DevicePublicKey = "device2"
+	copied.Operation = "copyKey"
+	copied.SessionId = "s2"
+	copied.SourceKeyId = "original"
+	copied.CreatedAt = createdAt.Add(time.Minute)
+	for _, metadata := range []KeyMetadata{copied, original} {
+		if err := store.Save(metadata); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, ok, err := store.Get("copy")
+	if err != nil || !ok {
+		t.Fatalf("Get = %v, %v", ok, err)
+	}
+	if *got != copied {
+		t.Errorf("Get = %+v, want %+v", *got, copied)
+	}
+	if _, ok, err := store.Get("unknown"); ok || err != nil {
+		t.Errorf("Get(unknown) = %v, %v", ok, err)
+	}
+
+	all, err := store.Find(Query{UserId: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].KeyId != "original" || all[1].KeyId != "copy" {
+		t.Errorf("Find(user) = %+v, want original then copy", all)
+	}
+	copies, err := store.Find(Query{SourceKeyId: "original", DevicePublicKey: "device2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(copies) != 1 || copies[0].KeyId != "copy" {
+		t.Errorf("Find(copies) = %+v", copies)
+	}
+
+	// 같은 keyId 를 다시 저장하면 덮어씁니다.
+	original.PublicKey = "pkix"
+	if err := store.Save(original); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := store.Get("original"); got.PublicKey != "pkix" {
+		t.Errorf("PublicKey after update = %s, want pkix", got.PublicKey)
+	}
+}
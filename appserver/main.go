@@ -10,9 +10,11 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ahnlabio/tsm-appserver/auth"
 	"github.com/ahnlabio/tsm-appserver/config"
 	"github.com/ahnlabio/tsm-appserver/container"
 	"github.com/ahnlabio/tsm-appserver/docs"
+	"github.com/ahnlabio/tsm-appserver/metrics"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -32,16 +34,37 @@ func main() {
 
 func getRouter() *gin.Engine {
 	r := gin.Default()
-	handlers := container.GetInstnace().GetHandlers()
+	appContainer := container.GetInstnace()
+	handlers := appContainer.GetHandlers()
 
 	r.GET("/", rootHandler)
 	r.GET("/swagger/*any", func(c *gin.Context) {
 		ginSwagger.WrapHandler(swaggerFiles.Handler)(c)
 	})
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 	r.POST("/v1/tsm/generateKey", handlers.GenerateKeyHandler)
 	r.POST("/v1/tsm/copyKey", handlers.CopyKeyHandler)
+	r.POST("/v1/tsm/reshareKey", handlers.ReshareKeyHandler)
+	r.GET("/v1/tsm/wrappingKeys", handlers.WrappingKeysHandler)
+	r.POST("/v1/tsm/importKey", handlers.ImportKeyHandler)
 	r.POST("/v1/tsm/preSign", handlers.PreSignHandler)
 	r.POST("/v1/tsm/finalizeSign", handlers.PartialSignHandler)
+	r.POST("/v1/tsm/finalizeSignBatch", handlers.PartialSignBatchHandler)
+	r.GET("/v1/tsm/keys", handlers.ListKeysHandler)
+	r.GET("/v1/tsm/keys/:keyId/publicKey", handlers.PublicKeyHandler)
+	r.POST("/v1/tsm/keys/:keyId/revoke", handlers.RevokeKeyHandler)
+	r.GET("/v1/tsm/keys/:keyId/revocation", handlers.GetRevocationHandler)
+	r.GET("/v1/tsm/keys/:keyId/metadata", handlers.GetKeyMetadataHandler)
+	r.GET("/v1/tsm/keys/:keyId/lineage", handlers.KeyLineageHandler)
+	r.GET("/v1/tsm/keyMetadata", handlers.FindKeyMetadataHandler)
+	r.POST("/v1/tsm/keys/:keyId/backup", handlers.BackupKeySharesHandler)
+	r.POST("/v1/tsm/keys/:keyId/recoveryData", handlers.RecoveryDataHandler)
+	r.GET("/v1/tsm/sessions/:sessionId", handlers.GetSessionHandler)
+	r.POST("/v1/tsm/sessions/:sessionId/cancel", handlers.CancelSessionHandler)
+	if verifier := appContainer.GetCallbackVerifier(); verifier != nil {
+		// player(controller) 만 호출할 수 있습니다.
+		r.POST("/v1/tsm/callbacks/sessions", auth.Middleware(verifier), handlers.SessionCallbackHandler)
+	}
 	return r
 }
 
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const NAMESPACE string = "tsm_appserver"
+
+const (
+	PARTIAL_SIGN       string = "partialSign"
+	PARTIAL_SIGN_BATCH string = "partialSignBatch"
+)
+
+const (
+	SUCCEEDED string = "succeeded"
+	FAILED    string = "failed"
+	// player 에 연결하지 못했거나 응답을 읽지 못한 경우
+	UNAVAILABLE string = "unavailable"
+)
+
+// MPC session 은 mobile player 를 기다리므로 수 분까지 걸릴 수 있습니다.
+var durationBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+type Metrics struct {
+	operations     *prometheus.CounterVec
+	duration       *prometheus.HistogramVec
+	playerRequests *prometheus.HistogramVec
+}
+
+// New registers the metrics. inFlight returns the number of pending sessions per operation.
+func New(registerer prometheus.Registerer, inFlight func() map[string]int) *Metrics {
+	m := &Metrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "operations_total",
+			Help:      "Number of finished operations by outcome and player.",
+		}, []string{"operation", "outcome", "player_index"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NAMESPACE,
+			Name:      "operation_duration_seconds",
+			Help:      "Time from the request to the completion reported by the player.",
+			Buckets:   durationBuckets,
+		}, []string{"operation", "outcome", "player_index"}),
+		playerRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NAMESPACE,
+			Name:      "player_request_duration_seconds",
+			Help:      "Latency of requests to the players (controllers).",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"player_index", "player_url", "method", "outcome"}),
+	}
+	registerer.MustRegister(m.operations, m.duration, m.playerRequests, &sessionCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(NAMESPACE, "", "sessions_in_flight"),
+			"Number of sessions waiting for the result of a player. Sessions stay pending if session callbacks are disabled.",
+			[]string{"operation"},
+			nil,
+		),
+		inFlight: inFlight,
+	})
+	return m
+}
+
+func (m *Metrics) Observe(operation string, outcome string, playerIndex string, duration time.Duration) {
+	m.operations.WithLabelValues(operation, outcome, playerIndex).Inc()
+	m.duration.WithLabelValues(operation, outcome, playerIndex).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObservePlayerRequest(playerIndex string, playerUrl string, method string, outcome string, duration time.Duration) {
+	m.playerRequests.WithLabelValues(playerIndex, playerUrl, method, outcome).Observe(duration.Seconds())
+}
+
+// Handler serves the metrics of the default registry, including go runtime and process metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// sessionCollector 는 scrape 할 때 session tracker 에서 진행 중인 session 수를 읽습니다.
+type sessionCollector struct {
+	desc     *prometheus.Desc
+	inFlight func() map[string]int
+}
+
+func (c *sessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *sessionCollector) Collect(ch chan<- prometheus.Metric) {
+	for operation, count := range c.inFlight() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), operation)
+	}
+}
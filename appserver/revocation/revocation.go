@@ -0,0 +1,48 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+)
+
+type Revocation struct {
+	KeyId           string    `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	DevicePublicKey string    `json:"devicePublicKey,omitempty" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	RevokedBy       string    `json:"revokedBy" example:"support@ahnlab.io"`
+	Reason          string    `json:"reason" example:"device lost"`
+	RevokedAt       time.Time `json:"revokedAt"`
+}
+
+// Store records who revoked a key and why.
+type Store interface {
+	Save(revocation Revocation) error
+	Get(keyId string) (*Revocation, bool, error)
+}
+
+type MemoryStore struct {
+	mu          sync.RWMutex
+	revocations map[string]Revocation
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{revocations: make(map[string]Revocation)}
+}
+
+func (m *MemoryStore) Save(revocation Revocation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.revocations[revocation.KeyId] = revocation
+	return nil
+}
+
+func (m *MemoryStore) Get(keyId string) (*Revocation, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revocation, ok := m.revocations[keyId]
+	if !ok {
+		return nil, false, nil
+	}
+	return &revocation, true, nil
+}
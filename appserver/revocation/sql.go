@@ -0,0 +1,113 @@
+package revocation
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	POSTGRES string = "postgres"
+	SQLITE   string = "sqlite3"
+)
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS key_revocation (
+		key_id            VARCHAR(255) PRIMARY KEY,
+		device_public_key TEXT NOT NULL DEFAULT '',
+		revoked_by        VARCHAR(255) NOT NULL,
+		reason            TEXT NOT NULL,
+		revoked_at        TIMESTAMP NOT NULL
+	)`,
+}
+
+const columns = "key_id, device_public_key, revoked_by, reason, revoked_at"
+
+// SQLStore keeps revocations in SQLite or PostgreSQL.
+type SQLStore struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewSQLStore opens the database and creates the key_revocation table if it doesn't exist.
+func NewSQLStore(driver string, dsn string) (*SQLStore, error) {
+	if driver != POSTGRES && driver != SQLITE {
+		return nil, fmt.Errorf("unsupported revocation driver: %s", driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == SQLITE {
+		// sqlite 는 동시에 하나의 writer 만 허용하므로 connection 을 하나만 사용합니다.
+		db.SetMaxOpenConns(1)
+	}
+	for _, statement := range schema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create key_revocation table: %w", err)
+		}
+	}
+	return &SQLStore{db: db, driver: driver}, nil
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) Save(revocation Revocation) error {
+	query := s.rebind(`INSERT INTO key_revocation (` + columns + `) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (key_id) DO UPDATE SET
+			device_public_key = excluded.device_public_key,
+			revoked_by = excluded.revoked_by,
+			reason = excluded.reason,
+			revoked_at = excluded.revoked_at`)
+	_, err := s.db.Exec(query,
+		revocation.KeyId,
+		revocation.DevicePublicKey,
+		revocation.RevokedBy,
+		revocation.Reason,
+		revocation.RevokedAt.UTC(),
+	)
+	return err
+}
+
+func (s *SQLStore) Get(keyId string) (*Revocation, bool, error) {
+	var revocation Revocation
+	err := s.db.QueryRow(s.rebind(`SELECT `+columns+` FROM key_revocation WHERE key_id = ?`), keyId).Scan(
+		&revocation.KeyId,
+		&revocation.DevicePublicKey,
+		&revocation.RevokedBy,
+		&revocation.Reason,
+		&revocation.RevokedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	revocation.RevokedAt = revocation.RevokedAt.UTC()
+	return &revocation, true, nil
+}
+
+// rebind 는 postgres 에서 ? placeholder 를 $1, $2 ... 로 바꿉니다.
+func (s *SQLStore) rebind(query string) string {
+	if s.driver != POSTGRES {
+		return query
+	}
+	var builder strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&builder, "$%d", n)
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,54 @@
+package revocation
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	postgres := &SQLStore{driver: POSTGRES}
+	if got, want := postgres.rebind(`VALUES (?, ?)`), `VALUES ($1, $2)`; got != want {
+		t.Errorf("postgres rebind = %s, want %s", got, want)
+	}
+	sqlite := &SQLStore{driver: SQLITE}
+	if got := sqlite.rebind(`VALUES (?, ?)`); got != `VALUES (?, ?)` {
+		t.Errorf("sqlite rebind = %s, want the query unchanged", got)
+	}
+}
+
+func TestSQLStore(t *testing.T) {
+	store, err := NewSQLStore(SQLITE, "file:"+filepath.Join(t.TempDir(), "revocation.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if _, ok, err := store.Get("k"); ok || err != nil {
+		t.Fatalf("Get before save = %v, %v", ok, err)
+	}
+
+	revocation := Revocation{
+		KeyId:           "k",
+		DevicePublicKey: "device",
+		RevokedBy:       "support@ahnlab.io",
+		Reason:          "device lost",
+		RevokedAt:       time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+	}
+	if err := store.Save(revocation); err != nil {
+		t.Fatal(err)
+	}
+	// 다시 revoke 하면 마지막 기록을 남깁니다.
+	revocation.Reason = "device stolen"
+	if err := store.Save(revocation); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok, err := store.Get("k")
+	if err != nil || !ok {
+		t.Fatalf("Get = %v, %v", ok, err)
+	}
+	if *got != revocation {
+		t.Errorf("Get = %+v, want %+v", *got, revocation)
+	}
+}
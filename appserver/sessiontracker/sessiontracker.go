@@ -0,0 +1,218 @@
+package sessiontracker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PENDING   string = "pending"
+	SUCCEEDED string = "succeeded"
+	FAILED    string = "failed"
+	CANCELLED string = "cancelled"
+)
+
+const (
+	GENERATE_KEY string = "generateKey"
+	COPY_KEY     string = "copyKey"
+	PRESIGN      string = "preSign"
+	RESHARE      string = "reshareKey"
+	IMPORT_KEY   string = "importKey"
+)
+
+// finished sessions are kept for this long so clients can poll the result.
+const retention = 24 * time.Hour
+
+// Event is the completion event a player (controller) posts when its part of a session finishes.
+type Event struct {
+	SessionId       string    `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	PlayerIndex     string    `json:"playerIndex" binding:"required" example:"1"`
+	Operation       string    `json:"operation" binding:"required" example:"generateKey"`
+	Status          string    `json:"status" binding:"required" example:"succeeded"`
+	KeyId           string    `json:"keyId,omitempty" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	PresignatureIds []string  `json:"presignatureIds,omitempty"`
+	ErrorText       string    `json:"errorText,omitempty" example:"SESSION_TIMEOUT"`
+	Error           string    `json:"error,omitempty"`
+	FinishedAt      time.Time `json:"finishedAt"`
+}
+
+// Session is the result of a session correlated over the players that take part in it.
+type Session struct {
+	SessionId string            `json:"sessionId" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	Operation string            `json:"operation" example:"generateKey"`
+	Status    string            `json:"status" example:"succeeded"`
+	KeyId     string            `json:"keyId,omitempty" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Players   map[string]*Event `json:"players"`
+	Error     string            `json:"error,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+type Tracker struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{sessions: make(map[string]*Session)}
+}
+
+// Create registers a session started by the appserver. It does nothing if a player already reported it.
+func (t *Tracker) Create(sessionId string, operation string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune()
+	t.getOrCreate(sessionId, operation)
+}
+
+// Record stores a player's completion event and returns the correlated session.
+// An event of a player that has already reported is ignored, so a retried or late callback can't change the result.
+func (t *Tracker) Record(event Event) (Session, error) {
+	if event.PlayerIndex != "1" && event.PlayerIndex != "2" {
+		return Session{}, fmt.Errorf("invalid playerIndex: %s", event.PlayerIndex)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// appserver 가 재시작된 경우 session 이 없을 수 있으므로 event 로 만듭니다.
+	s := t.getOrCreate(event.SessionId, event.Operation)
+	if s.Operation != event.Operation {
+		return Session{}, fmt.Errorf("operation mismatch. session: %s, event: %s", s.Operation, event.Operation)
+	}
+
+	if _, reported := s.Players[event.PlayerIndex]; reported {
+		return copySession(s), nil
+	}
+	s.Players[event.PlayerIndex] = &event
+	s.UpdatedAt = time.Now()
+	resolve(s)
+	return copySession(s), nil
+}
+
+// InFlight returns the number of pending sessions per operation.
+func (t *Tracker) InFlight() map[string]int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	inFlight := map[string]int{GENERATE_KEY: 0, COPY_KEY: 0, PRESIGN: 0, RESHARE: 0, IMPORT_KEY: 0}
+	for _, s := range t.sessions {
+		if s.Status == PENDING {
+			inFlight[s.Operation]++
+		}
+	}
+	return inFlight
+}
+
+// Get returns a copy of the session.
+func (t *Tracker) Get(sessionId string) (Session, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	s, ok := t.sessions[sessionId]
+	if !ok {
+		return Session{}, false
+	}
+	return copySession(s), true
+}
+
+func (t *Tracker) getOrCreate(sessionId string, operation string) *Session {
+	// caller must hold t.mu
+	if s, ok := t.sessions[sessionId]; ok {
+		return s
+	}
+	now := time.Now()
+	s := &Session{
+		SessionId: sessionId,
+		Operation: operation,
+		Status:    PENDING,
+		Players:   make(map[string]*Event),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	t.sessions[sessionId] = s
+	return s
+}
+
+func (t *Tracker) prune() {
+	// caller must hold t.mu
+	cutoff := time.Now().Add(-retention)
+	for id, s := range t.sessions {
+		if s.UpdatedAt.Before(cutoff) {
+			delete(t.sessions, id)
+		}
+	}
+}
+
+// expectedPlayers 는 operation 에 참여하는 player 입니다. preSign 은 player1 에서만 실행됩니다.
+func expectedPlayers(operation string) []string {
+	if operation == PRESIGN {
+		return []string{"1"}
+	}
+	return []string{"1", "2"}
+}
+
+func resolve(s *Session) {
+	/*
+		player 의 결과를 모아 session 의 상태를 정합니다.
+		한 player 라도 실패하면 다른 player 를 기다리지 않고 실패로 처리합니다.
+		모든 player 가 성공하면 같은 keyId 를 받았는지 확인합니다.
+	*/
+	var failures []string
+	cancelled := false
+	pending := false
+	keyIds := make(map[string]bool)
+
+	for _, playerIndex := range expectedPlayers(s.Operation) {
+		event, ok := s.Players[playerIndex]
+		if !ok {
+			pending = true
+			continue
+		}
+		switch event.Status {
+		case SUCCEEDED:
+			keyIds[event.KeyId] = true
+		case CANCELLED:
+			cancelled = true
+		default:
+			failures = append(failures, fmt.Sprintf("player%s: %s %s", playerIndex, event.ErrorText, event.Error))
+		}
+	}
+
+	switch {
+	case len(failures) > 0:
+		s.Status = FAILED
+		s.Error = strings.Join(failures, "; ")
+	case cancelled:
+		s.Status = CANCELLED
+	case pending:
+		s.Status = PENDING
+	case len(keyIds) > 1:
+		ids := make([]string, 0, len(keyIds))
+		for keyId := range keyIds {
+			ids = append(ids, keyId)
+		}
+		sort.Strings(ids)
+		s.Status = FAILED
+		s.Error = fmt.Sprintf("players reported different key ids: %s", strings.Join(ids, ", "))
+	default:
+		s.Status = SUCCEEDED
+		for keyId := range keyIds {
+			s.KeyId = keyId
+		}
+	}
+}
+
+func copySession(s *Session) Session {
+	session := *s
+	session.Players = make(map[string]*Event, len(s.Players))
+	for playerIndex, event := range s.Players {
+		e := *event
+		session.Players[playerIndex] = &e
+	}
+	return session
+}
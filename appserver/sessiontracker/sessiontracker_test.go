@@ -0,0 +1,109 @@
+package sessiontracker
+
+import (
+	"testing"
+)
+
+func event(sessionId string, playerIndex string, operation string, status string, keyId string) Event {
+	return Event{SessionId: sessionId, PlayerIndex: playerIndex, Operation: operation, Status: status, KeyId: keyId}
+}
+
+func record(t *testing.T, tracker *Tracker, e Event) Session {
+	t.Helper()
+	s, err := tracker.Record(e)
+	if err != nil {
+		t.Fatalf("Record(%+v) error: %v", e, err)
+	}
+	return s
+}
+
+func TestTrackerSucceedsWhenAllPlayersReportTheSameKey(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Create("s", GENERATE_KEY)
+
+	if s := record(t, tracker, event("s", "1", GENERATE_KEY, SUCCEEDED, "k")); s.Status != PENDING {
+		t.Errorf("status after player1 = %s, want %s", s.Status, PENDING)
+	}
+	s := record(t, tracker, event("s", "2", GENERATE_KEY, SUCCEEDED, "k"))
+	if s.Status != SUCCEEDED || s.KeyId != "k" {
+		t.Errorf("session = %s %s, want %s k", s.Status, s.KeyId, SUCCEEDED)
+	}
+}
+
+func TestTrackerFailsOnDifferentKeyIds(t *testing.T) {
+	tracker := NewTracker()
+	record(t, tracker, event("s", "1", COPY_KEY, SUCCEEDED, "a"))
+	s := record(t, tracker, event("s", "2", COPY_KEY, SUCCEEDED, "b"))
+	if s.Status != FAILED || s.KeyId != "" {
+		t.Errorf("session = %s %s, want %s without keyId", s.Status, s.KeyId, FAILED)
+	}
+}
+
+func TestTrackerFailsWithoutWaitingForOtherPlayer(t *testing.T) {
+	tracker := NewTracker()
+	s := record(t, tracker, event("s", "2", RESHARE, FAILED, ""))
+	if s.Status != FAILED {
+		t.Errorf("status = %s, want %s", s.Status, FAILED)
+	}
+
+	cancelled := record(t, tracker, event("c", "1", IMPORT_KEY, CANCELLED, ""))
+	if cancelled.Status != CANCELLED {
+		t.Errorf("status = %s, want %s", cancelled.Status, CANCELLED)
+	}
+}
+
+func TestTrackerPresignOnlyWaitsForPlayer1(t *testing.T) {
+	tracker := NewTracker()
+	s := record(t, tracker, event("s", "1", PRESIGN, SUCCEEDED, "k"))
+	if s.Status != SUCCEEDED {
+		t.Errorf("status = %s, want %s", s.Status, SUCCEEDED)
+	}
+}
+
+func TestTrackerIgnoresRepeatedPlayerEvent(t *testing.T) {
+	tracker := NewTracker()
+	record(t, tracker, event("s", "1", GENERATE_KEY, FAILED, ""))
+	s := record(t, tracker, event("s", "1", GENERATE_KEY, SUCCEEDED, "k"))
+	if s.Status != FAILED || s.Players["1"].Status != FAILED {
+		t.Errorf("session = %s, player1 = %s, want the first event to be kept", s.Status, s.Players["1"].Status)
+	}
+}
+
+func TestTrackerRejectsInvalidEvents(t *testing.T) {
+	tracker := NewTracker()
+	if _, err := tracker.Record(event("s", "3", GENERATE_KEY, SUCCEEDED, "k")); err == nil {
+		t.Error("expected an error for playerIndex 3")
+	}
+
+	tracker.Create("s", GENERATE_KEY)
+	if _, err := tracker.Record(event("s", "1", COPY_KEY, SUCCEEDED, "k")); err == nil {
+		t.Error("expected an error for an operation mismatch")
+	}
+}
+
+func TestTrackerReturnsCopies(t *testing.T) {
+	tracker := NewTracker()
+	s := record(t, tracker, event("s", "1", GENERATE_KEY, SUCCEEDED, "k"))
+	s.Players["1"].KeyId = "changed"
+
+	stored, ok := tracker.Get("s")
+	if !ok {
+		t.Fatal("session not found")
+	}
+	if stored.Players["1"].KeyId != "k" {
+		t.Errorf("stored event was changed through a returned session")
+	}
+}
+
+func TestTrackerInFlight(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Create("a", GENERATE_KEY)
+	tracker.Create("b", GENERATE_KEY)
+	tracker.Create("c", PRESIGN)
+	record(t, tracker, event("c", "1", PRESIGN, SUCCEEDED, "k"))
+
+	inFlight := tracker.InFlight()
+	if inFlight[GENERATE_KEY] != 2 || inFlight[PRESIGN] != 0 {
+		t.Errorf("InFlight = %v, want 2 %s and 0 %s", inFlight, GENERATE_KEY, PRESIGN)
+	}
+}
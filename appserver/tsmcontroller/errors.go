@@ -0,0 +1,133 @@
+package tsmcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	INVALID_INPUT string = "INVALID_INPUT"
+	NOT_FOUND     string = "NOT_FOUND"
+	FORBIDDEN     string = "FORBIDDEN"
+	PLAYER_ERROR  string = "PLAYER_ERROR"
+
+	INVALID_RECOVERY_DATA string = "INVALID_RECOVERY_DATA"
+
+	// player(controller) 가 반환하는 error text
+	WRONG_ROLE       string = "WRONG_ROLE"
+	NODE_UNAVAILABLE string = "NODE_UNAVAILABLE"
+	KEY_NOT_FOUND    string = "KEY_NOT_FOUND"
+	SESSION_FAILED   string = "SESSION_FAILED"
+	POLICY_DENIED    string = "POLICY_DENIED"
+)
+
+type SvcErr struct {
+	Status int
+	Text   string
+	Msg    string
+}
+
+func (e SvcErr) Error() string {
+	return e.Msg
+}
+
+func InvalidInputError(err error) *SvcErr {
+	return &SvcErr{
+		Status: http.StatusBadRequest,
+		Text:   INVALID_INPUT,
+		Msg:    err.Error(),
+	}
+}
+
+func NotFoundError(err error) *SvcErr {
+	return &SvcErr{
+		Status: http.StatusNotFound,
+		Text:   NOT_FOUND,
+		Msg:    err.Error(),
+	}
+}
+
+func ForbiddenError(err error) *SvcErr {
+	return &SvcErr{
+		Status: http.StatusForbidden,
+		Text:   FORBIDDEN,
+		Msg:    err.Error(),
+	}
+}
+
+// PlayerError 는 player(controller) 호출 실패를 변환합니다.
+// player 가 4xx 를 반환한 경우 요청자의 잘못이므로 status 와 error text 를 그대로 전달합니다.
+// 단 401 과 POLICY_DENIED 가 아닌 403 은 appserver 와 player 사이의 인증이나 설정 문제이므로 502 로 응답합니다.
+// NODE_UNAVAILABLE 은 503 으로, 그 외의 경우는 player 의 error text 를 유지한 채 502 로 응답합니다.
+func PlayerError(url string, status int, body []byte) *SvcErr {
+	var errorBody struct {
+		Error struct {
+			Text    string `json:"text"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.Unmarshal(body, &errorBody)
+
+	misconfigured := status == http.StatusUnauthorized || (status == http.StatusForbidden && errorBody.Error.Text != POLICY_DENIED)
+	if status >= 400 && status < 500 && !misconfigured {
+		text := errorBody.Error.Text
+		if text == "" {
+			text = PLAYER_ERROR
+		}
+		message := errorBody.Error.Message
+		if message == "" {
+			message = fmt.Sprintf("player rejected the request. url: %s, status: %d", url, status)
+		}
+		return &SvcErr{
+			Status: status,
+			Text:   text,
+			Msg:    message,
+		}
+	}
+
+	if misconfigured {
+		// 요청자가 appserver 에 인증하지 못한 것으로 오해하지 않도록 player 의 error text 를 전달하지 않습니다.
+		return &SvcErr{
+			Status: http.StatusBadGateway,
+			Text:   PLAYER_ERROR,
+			Msg:    fmt.Sprintf("player rejected the appserver. url: %s, status: %d, body: %s", url, status, string(body)),
+		}
+	}
+
+	if status == http.StatusServiceUnavailable || errorBody.Error.Text == NODE_UNAVAILABLE {
+		return &SvcErr{
+			Status: http.StatusServiceUnavailable,
+			Text:   NODE_UNAVAILABLE,
+			Msg:    fmt.Sprintf("player node is unavailable. url: %s, message: %s", url, errorBody.Error.Message),
+		}
+	}
+
+	text := errorBody.Error.Text
+	if text == "" {
+		text = PLAYER_ERROR
+	}
+	return &SvcErr{
+		Status: http.StatusBadGateway,
+		Text:   text,
+		Msg:    fmt.Sprintf("player request failed. url: %s, status: %d, body: %s", url, status, string(body)),
+	}
+}
+
+// PlayerUnavailableError 는 player(controller) 에 연결할 수 없는 경우입니다.
+func PlayerUnavailableError(url string, err error) *SvcErr {
+	return &SvcErr{
+		Status: http.StatusServiceUnavailable,
+		Text:   NODE_UNAVAILABLE,
+		Msg:    fmt.Sprintf("player request failed. url: %s, error: %s", url, err),
+	}
+}
+
+// InvalidRecoveryDataError 는 player 의 partial recovery data 를 합치거나 검증하지 못한 경우입니다.
+func InvalidRecoveryDataError(err error) *SvcErr {
+	return &SvcErr{
+		Status: http.StatusBadGateway,
+		Text:   INVALID_RECOVERY_DATA,
+		Msg:    err.Error(),
+	}
+}
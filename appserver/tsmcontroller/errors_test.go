@@ -0,0 +1,37 @@
+package tsmcontroller
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPlayerError(t *testing.T) {
+	const url = "http://player1/v1/partialSign"
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+		wantText   string
+	}{
+		{"invalid input", http.StatusBadRequest, `{"error":{"text":"INVALID_INPUT","message":"bad"}}`, http.StatusBadRequest, INVALID_INPUT},
+		{"key not found", http.StatusNotFound, `{"error":{"text":"KEY_NOT_FOUND","message":"no key"}}`, http.StatusNotFound, KEY_NOT_FOUND},
+		{"policy denied", http.StatusForbidden, `{"error":{"text":"POLICY_DENIED","message":"rate limit"}}`, http.StatusForbidden, POLICY_DENIED},
+		{"empty 4xx body", http.StatusConflict, ``, http.StatusConflict, PLAYER_ERROR},
+		{"unparsable 4xx body", http.StatusBadRequest, `<html>`, http.StatusBadRequest, PLAYER_ERROR},
+		{"unauthorized", http.StatusUnauthorized, `{"error":{"text":"UNAUTHORIZED","message":"invalid signature"}}`, http.StatusBadGateway, PLAYER_ERROR},
+		{"wrong role", http.StatusForbidden, `{"error":{"text":"WRONG_ROLE","message":"player2 can't sign"}}`, http.StatusBadGateway, PLAYER_ERROR},
+		{"node unavailable", http.StatusServiceUnavailable, `{"error":{"text":"NODE_UNAVAILABLE","message":"down"}}`, http.StatusServiceUnavailable, NODE_UNAVAILABLE},
+		{"session failed", http.StatusInternalServerError, `{"error":{"text":"SESSION_FAILED","message":"mpc"}}`, http.StatusBadGateway, SESSION_FAILED},
+		{"empty 5xx body", http.StatusInternalServerError, ``, http.StatusBadGateway, PLAYER_ERROR},
+	}
+	for _, test := range tests {
+		err := PlayerError(url, test.status, []byte(test.body))
+		if err.Status != test.wantStatus || err.Text != test.wantText {
+			t.Errorf("%s: got %d %s, want %d %s", test.name, err.Status, err.Text, test.wantStatus, test.wantText)
+		}
+		if err.Msg == "" {
+			t.Errorf("%s: message is empty", test.name)
+		}
+	}
+}
@@ -2,12 +2,25 @@ package tsmcontroller
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/ahnlabio/tsm-appserver/auth"
+	"github.com/ahnlabio/tsm-appserver/keymetadata"
+	"github.com/ahnlabio/tsm-appserver/metrics"
+	"github.com/ahnlabio/tsm-appserver/revocation"
+	"github.com/ahnlabio/tsm-appserver/sessiontracker"
+	"github.com/ahnlabio/tsm-appserver/tsmutils"
 	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
 )
 
@@ -15,56 +28,225 @@ type Player struct {
 	Url string `json:"url"`
 }
 
+// REQUEST_TIMEOUT 는 player 요청의 기본 timeout 입니다.
+// controller 는 session 을 background 에서 실행하고 바로 응답하므로 긴 timeout 이 필요하지 않습니다.
+const REQUEST_TIMEOUT = 10 * time.Second
+
+// PARTIAL_SIGN_BATCH_TIMEOUT 은 partialSignBatch 요청의 timeout 입니다.
+// controller 가 item 마다 policy 확인, 서명, audit log 기록을 순서대로 하므로 최대 batch 를 서명할 수 있을 만큼 기다립니다.
+// 먼저 끊으면 controller 가 presignature 를 사용하고 만든 서명을 잃어버립니다.
+const PARTIAL_SIGN_BATCH_TIMEOUT = 2 * time.Minute
+
+// RECOVERY_DATA_TIMEOUT 은 recoveryData 요청의 timeout 입니다.
+// controller 가 ERS_TIMEOUT 동안 다른 player 를 기다리므로 그보다 길어야 controller 의 SESSION_TIMEOUT 을 받을 수 있습니다.
+const RECOVERY_DATA_TIMEOUT = 3 * time.Minute
+
 type TSMController struct {
-	Player1 Player
-	Player2 Player
+	Player1     Player
+	Player2     Player
+	Revocations revocation.Store
+	KeyMetadata keymetadata.Store
+	Sessions    *sessiontracker.Tracker
+
+	pendingKeys *keymetadata.Pending
+	metrics     *metrics.Metrics
+	httpClient  *http.Client
+	signer      auth.Signer
 }
 
-func NewTSMController(player1 Player, player2 Player) *TSMController {
+func NewTSMController(player1 Player, player2 Player, revocations revocation.Store, keyMetadata keymetadata.Store, sessions *sessiontracker.Tracker, serviceMetrics *metrics.Metrics, httpClient *http.Client, signer auth.Signer) *TSMController {
 	return &TSMController{
-		Player1: player1,
-		Player2: player2,
+		Player1:     player1,
+		Player2:     player2,
+		Revocations: revocations,
+		KeyMetadata: keyMetadata,
+		Sessions:    sessions,
+		pendingKeys: keymetadata.NewPending(),
+		metrics:     serviceMetrics,
+		httpClient:  httpClient,
+		signer:      signer,
 	}
 }
 
 type GenerateKeyRequestBody struct {
 	SessionId string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
 	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	Algorithm string `json:"algorithm,omitempty" example:"schnorr"`
+	Curve     string `json:"curve,omitempty" example:"ED-25519"`
+	Threshold int    `json:"threshold,omitempty" example:"1"`
 }
 
-func (t *TSMController) StartGenerateKeySession(publicKey string) string {
+func (t *TSMController) StartGenerateKeySession(userId string, publicKey string, algorithm string, curve string, threshold int) (string, error) {
 	sessionId := tsm.GenerateSessionID()
 
-	requestBody := GenerateKeyRequestBody{SessionId: sessionId, PublicKey: publicKey}
+	// player1, player2 는 같은 요청을 받아야 같은 session 에 참여할 수 있습니다.
+	requestBody := GenerateKeyRequestBody{SessionId: sessionId, PublicKey: publicKey, Algorithm: algorithm, Curve: curve, Threshold: threshold}
 	log.Printf("[StartGenerateKeySession] %v", requestBody)
-	player1GenKeyUrl := fmt.Sprintf("%s/v1/generateKey", t.Player1.Url)
-	go httpRequest(player1GenKeyUrl, "POST", requestBody)
-
-	player2GenKeyUrl := fmt.Sprintf("%s/v1/generateKey", t.Player2.Url)
-	go httpRequest(player2GenKeyUrl, "POST", requestBody)
+	err := t.requestPlayers("POST", "/v1/generateKey", sessionId, requestBody, t.Player1, t.Player2)
+	if err != nil {
+		return "", err
+	}
+	// 결과는 각 player 의 callback 으로 전달됩니다.
+	t.Sessions.Create(sessionId, sessiontracker.GENERATE_KEY)
+	t.pendingKeys.Add(keymetadata.KeyMetadata{
+		UserId:          userId,
+		DevicePublicKey: publicKey,
+		Algorithm:       algorithm,
+		Curve:           curve,
+		Operation:       sessiontracker.GENERATE_KEY,
+		SessionId:       sessionId,
+		CreatedAt:       time.Now(),
+	})
 
-	return sessionId
+	return sessionId, nil
 }
 
 type CopyKeyRequestBody struct {
 	SessionId     string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
 	PublicKey     string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
 	ExistingKeyId string `json:"existingKeyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm     string `json:"algorithm,omitempty" example:"schnorr"`
+	Curve         string `json:"curve,omitempty" example:"ED-25519"`
+	Threshold     int    `json:"threshold,omitempty" example:"1"`
 }
 
-func (t *TSMController) StartCopyKeySession(publicKey string, existingKeyID string) string {
+func (t *TSMController) StartCopyKeySession(userId string, publicKey string, existingKeyID string, algorithm string, curve string, threshold int) (string, error) {
 	/*
 		/v1/copyKey
 	*/
 	sessionId := tsm.GenerateSessionID()
+	requestBody := CopyKeyRequestBody{SessionId: sessionId, PublicKey: publicKey, ExistingKeyId: existingKeyID, Algorithm: algorithm, Curve: curve, Threshold: threshold}
+
+	log.Printf("[StartCopyKeySession] %v", requestBody)
+	err := t.requestPlayers("POST", "/v1/copyKey", sessionId, requestBody, t.Player1, t.Player2)
+	if err != nil {
+		return "", err
+	}
+	t.Sessions.Create(sessionId, sessiontracker.COPY_KEY)
+	t.pendingKeys.Add(keymetadata.KeyMetadata{
+		UserId:          userId,
+		DevicePublicKey: publicKey,
+		Algorithm:       algorithm,
+		Curve:           curve,
+		Operation:       sessiontracker.COPY_KEY,
+		SessionId:       sessionId,
+		SourceKeyId:     existingKeyID,
+		CreatedAt:       time.Now(),
+	})
+
+	return sessionId, nil
+}
+
+type WrappingKeyResponseBody struct {
+	WrappingKey string `json:"wrappingKey" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."`
+}
+
+type WrappingKeysResponseBody struct {
+	Player1 string `json:"player1" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."` // base64 SubjectPublicKeyInfo
+	Player2 string `json:"player2" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."` // base64 SubjectPublicKeyInfo
+}
+
+func (t *TSMController) WrappingKeys() (*WrappingKeysResponseBody, error) {
+	/*
+		/v1/wrappingKey
+		import 할 key 의 share 를 각 player 의 wrapping key 로 암호화해야 하므로 player1, player2 의 wrapping key 를 모아 반환합니다.
+	*/
+	wrappingKeys := make([]string, 2)
+	for i, player := range []Player{t.Player1, t.Player2} {
+		responseBodyBytes, err := t.httpRequest(fmt.Sprintf("%s/v1/wrappingKey", player.Url), "GET", nil)
+		if err != nil {
+			return nil, err
+		}
+		var responseBody WrappingKeyResponseBody
+		if err := json.Unmarshal(responseBodyBytes, &responseBody); err != nil {
+			log.Printf("[WrappingKeys] failed to json.Unmarshal. error: %s", err)
+			return nil, err
+		}
+		wrappingKeys[i] = responseBody.WrappingKey
+	}
+	return &WrappingKeysResponseBody{Player1: wrappingKeys[0], Player2: wrappingKeys[1]}, nil
+}
+
+// ImportShare is the key share of one player wrapped with that player's wrapping key.
+type ImportShare struct {
+	WrappedKeyShare  string `json:"wrappedKeyShare" binding:"required" example:"base64"`
+	WrappedChainCode string `json:"wrappedChainCode" example:"base64"`
+}
+
+type ImportKeyRequestBody struct {
+	SessionId        string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	PublicKey        string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	WrappedKeyShare  string `json:"wrappedKeyShare" binding:"required" example:"base64"`
+	WrappedChainCode string `json:"wrappedChainCode,omitempty" example:"base64"`
+	PKIXPublicKey    string `json:"pkixPublicKey" binding:"required" example:"MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="`
+	Algorithm        string `json:"algorithm,omitempty" example:"schnorr"`
+	Threshold        int    `json:"threshold,omitempty" example:"1"`
+}
+
+func (t *TSMController) StartImportKeySession(userId string, publicKey string, pkixPublicKey string, algorithm string, threshold int, player1Share ImportShare, player2Share ImportShare) (string, error) {
+	/*
+		/v1/importKey
+		player 마다 자신의 wrapping key 로 암호화된 share 를 받으므로 요청 body 가 다릅니다.
+		결과(keyId)는 각 player 의 callback 으로 전달됩니다.
+	*/
+	sessionId := tsm.GenerateSessionID()
+	log.Printf("[StartImportKeySession] sessionId: %s, publicKey: %s, pkixPublicKey: %s, algorithm: %s, threshold: %d", sessionId, publicKey, pkixPublicKey, algorithm, threshold)
+
+	requestBody := func(share ImportShare) ImportKeyRequestBody {
+		return ImportKeyRequestBody{
+			SessionId:        sessionId,
+			PublicKey:        publicKey,
+			WrappedKeyShare:  share.WrappedKeyShare,
+			WrappedChainCode: share.WrappedChainCode,
+			PKIXPublicKey:    pkixPublicKey,
+			Algorithm:        algorithm,
+			Threshold:        threshold,
+		}
+	}
+	requests := []playerRequest{
+		{player: t.Player1, body: requestBody(player1Share)},
+		{player: t.Player2, body: requestBody(player2Share)},
+	}
+	if err := t.requestEachPlayer("POST", "/v1/importKey", sessionId, requests...); err != nil {
+		return "", err
+	}
+	t.Sessions.Create(sessionId, sessiontracker.IMPORT_KEY)
+	t.pendingKeys.Add(keymetadata.KeyMetadata{
+		UserId:          userId,
+		DevicePublicKey: publicKey,
+		Algorithm:       algorithm,
+		PublicKey:       pkixPublicKey,
+		Operation:       sessiontracker.IMPORT_KEY,
+		SessionId:       sessionId,
+		CreatedAt:       time.Now(),
+	})
 
-	player1CopyKeyUrl := fmt.Sprintf("%s/v1/copyKey", t.Player1.Url)
-	go httpRequest(player1CopyKeyUrl, "POST", CopyKeyRequestBody{SessionId: sessionId, PublicKey: publicKey, ExistingKeyId: existingKeyID})
+	return sessionId, nil
+}
 
-	player2CopyKeyUrl := fmt.Sprintf("%s/v1/copyKey", t.Player2.Url)
-	go httpRequest(player2CopyKeyUrl, "POST", CopyKeyRequestBody{SessionId: sessionId, PublicKey: publicKey, ExistingKeyId: existingKeyID})
+type ReshareKeyRequestBody struct {
+	SessionId string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	KeyId     string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm string `json:"algorithm,omitempty" example:"schnorr"`
+}
 
-	return sessionId
+func (t *TSMController) StartReshareSession(publicKey string, keyId string, algorithm string) (string, error) {
+	/*
+		/v1/reshareKey
+		key 의 share 를 새로 만듭니다. public key 와 keyId 는 바뀌지 않고 이전 share 는 쓸 수 없게 됩니다.
+	*/
+	sessionId := tsm.GenerateSessionID()
+	requestBody := ReshareKeyRequestBody{SessionId: sessionId, PublicKey: publicKey, KeyId: keyId, Algorithm: algorithm}
+
+	log.Printf("[StartReshareSession] %v", requestBody)
+	err := t.requestPlayers("POST", "/v1/reshareKey", sessionId, requestBody, t.Player1, t.Player2)
+	if err != nil {
+		return "", err
+	}
+	t.Sessions.Create(sessionId, sessiontracker.RESHARE)
+
+	return sessionId, nil
 }
 
 type PresignRequestBody struct {
@@ -72,41 +254,61 @@ type PresignRequestBody struct {
 	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
 	KeyId     string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
 	Count     uint64 `json:"count" binding:"required" example:"3"`
+	Algorithm string `json:"algorithm,omitempty" example:"schnorr"`
 }
 
-func (t *TSMController) StartPresignSession(publicKey string, keyId string, count uint64) string {
+func (t *TSMController) StartPresignSession(publicKey string, keyId string, count uint64, algorithm string) (string, error) {
 	/*
 		/v1/preSign
 	*/
 
-	log.Printf("[StartPresignSession] publicKey: %s, keyId: %s, count: %d", publicKey, keyId, count)
+	log.Printf("[StartPresignSession] publicKey: %s, keyId: %s, count: %d, algorithm: %s", publicKey, keyId, count, algorithm)
 	sessionId := tsm.GenerateSessionID()
-	player1PresignUrl := fmt.Sprintf("%s/v1/preSign", t.Player1.Url)
-	go httpRequest(player1PresignUrl, "POST", PresignRequestBody{SessionId: sessionId, PublicKey: publicKey, KeyId: keyId, Count: count})
+	requestBody := PresignRequestBody{SessionId: sessionId, PublicKey: publicKey, KeyId: keyId, Count: count, Algorithm: algorithm}
+	err := t.requestPlayers("POST", "/v1/preSign", sessionId, requestBody, t.Player1)
+	if err != nil {
+		return "", err
+	}
+	t.Sessions.Create(sessionId, sessiontracker.PRESIGN)
 
-	return sessionId
+	return sessionId, nil
 }
 
 type PartialSignRequestBody struct {
-	SignSignatureId string `json:"signSignatureId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
-	MessageHash     string `json:"messageHash" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
-	KeyId           string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	SignSignatureId string   `json:"signSignatureId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	Mode            string   `json:"mode,omitempty" example:"hash"`
+	MessageHash     string   `json:"messageHash,omitempty" example:"MV9b23bQeMQ7isAGTkoBZGErH853yGk0W/yUx1iU7dM="`
+	Message         string   `json:"message,omitempty" example:"SGVsbG8sIHdvcmxkIQ=="`
+	KeyId           string   `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm       string   `json:"algorithm,omitempty" example:"schnorr"`
+	DerivationPath  []uint32 `json:"derivationPath,omitempty" example:"44,501,0"`
 }
 
 type PartialSignResponseBody struct {
 	Signature string `json:"signature" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
 }
 
-func (t *TSMController) PartialSign(preSignatureId string, messageHash string, keyId string) (string, error) {
+func (t *TSMController) PartialSign(preSignatureId string, mode string, messageHash string, message string, keyId string, algorithm string, derivationPath []uint32) (string, error) {
+	requestedAt := time.Now()
+	signature, err := t.partialSign(preSignatureId, mode, messageHash, message, keyId, algorithm, derivationPath)
+	// partial sign 은 player1 만 실행합니다.
+	t.metrics.Observe(metrics.PARTIAL_SIGN, outcome(err), "1", time.Since(requestedAt))
+	return signature, err
+}
+
+func (t *TSMController) partialSign(preSignatureId string, mode string, messageHash string, message string, keyId string, algorithm string, derivationPath []uint32) (string, error) {
 	/*
 		/v1/partialSign
 	*/
 
 	player1PartialSignUrl := fmt.Sprintf("%s/v1/partialSign", t.Player1.Url)
-	player1PartialSignResponseBody := httpRequest(player1PartialSignUrl, "POST", PartialSignRequestBody{SignSignatureId: preSignatureId, MessageHash: messageHash, KeyId: keyId})
+	player1PartialSignResponseBody, err := t.httpRequest(player1PartialSignUrl, "POST", PartialSignRequestBody{SignSignatureId: preSignatureId, Mode: mode, MessageHash: messageHash, Message: message, KeyId: keyId, Algorithm: algorithm, DerivationPath: derivationPath})
+	if err != nil {
+		return "", err
+	}
 
 	var responseBody PartialSignResponseBody
-	err := json.Unmarshal(player1PartialSignResponseBody, &responseBody)
+	err = json.Unmarshal(player1PartialSignResponseBody, &responseBody)
 	if err != nil {
 		log.Printf("[PartialSign] failed to json.Unmarshal. error: %s", err)
 		return "", err
@@ -115,36 +317,754 @@ func (t *TSMController) PartialSign(preSignatureId string, messageHash string, k
 	return responseBody.Signature, nil
 }
 
-func httpRequest(url string, method string, requestBody any) []byte {
+type PartialSignBatchRequestBody struct {
+	Items []PartialSignRequestBody `json:"items"`
+}
+
+type PlayerErrorObject struct {
+	Text    string `json:"text" example:"POLICY_DENIED"`
+	Message string `json:"message" example:"rate limit exceeded"`
+}
+
+type PartialSignBatchItemResult struct {
+	Signature string             `json:"signature,omitempty" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	Error     *PlayerErrorObject `json:"error,omitempty"`
+}
+
+type PartialSignBatchResponseBody struct {
+	Results []PartialSignBatchItemResult `json:"results"`
+}
+
+func (t *TSMController) PartialSignBatch(items []PartialSignRequestBody) ([]PartialSignBatchItemResult, error) {
+	requestedAt := time.Now()
+	results, err := t.partialSignBatch(items)
+	t.metrics.Observe(metrics.PARTIAL_SIGN_BATCH, outcome(err), "1", time.Since(requestedAt))
+	return results, err
+}
+
+func (t *TSMController) partialSignBatch(items []PartialSignRequestBody) ([]PartialSignBatchItemResult, error) {
+	/*
+		/v1/partialSignBatch
+		item 별 결과는 요청 순서와 같습니다. 실패한 item 은 signature 대신 error 를 가지며 나머지 item 에 영향을 주지 않습니다.
+	*/
+
+	player1PartialSignBatchUrl := fmt.Sprintf("%s/v1/partialSignBatch", t.Player1.Url)
+	player1PartialSignBatchResponseBody, err := t.httpRequestContext(context.Background(), PARTIAL_SIGN_BATCH_TIMEOUT, player1PartialSignBatchUrl, "POST", PartialSignBatchRequestBody{Items: items})
+	if err != nil {
+		return nil, err
+	}
+
+	var responseBody PartialSignBatchResponseBody
+	err = json.Unmarshal(player1PartialSignBatchResponseBody, &responseBody)
+	if err != nil {
+		log.Printf("[PartialSignBatch] failed to json.Unmarshal. error: %s", err)
+		return nil, err
+	}
+	if len(responseBody.Results) != len(items) {
+		body := fmt.Sprintf("expected %d results, got %d", len(items), len(responseBody.Results))
+		return nil, PlayerError(player1PartialSignBatchUrl, http.StatusBadGateway, []byte(body))
+	}
+
+	return responseBody.Results, nil
+}
+
+type PublicKeyResponseBody struct {
+	KeyId          string `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm      string `json:"algorithm" example:"schnorr"`
+	DerivationPath string `json:"derivationPath" example:"m/44/501/0"`
+	PKIX           string `json:"pkix" example:"MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="`
+	Hex            string `json:"hex" example:"d61bf425f83d54872146da73584ac7207735cfd1047fc77d9b8a10e86fcbc0e8"`
+	Base64         string `json:"base64" example:"1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="`
+	Base58         string `json:"base58" example:"FQnyF8mUwgUapn3mjrcoCsURrmawEitKsMHkWfdYKtGP"`
+}
+
+func (t *TSMController) PublicKey(keyId string, algorithm string, derivationPath string) (*PublicKeyResponseBody, error) {
+	/*
+		/v1/keys/:keyId/publicKey
+		public key 는 모든 player 가 같으므로 player1 에게만 요청합니다.
+	*/
+	query := url.Values{}
+	if algorithm != "" {
+		query.Set("algorithm", algorithm)
+	}
+	if derivationPath != "" {
+		query.Set("derivationPath", derivationPath)
+	}
+
+	player1PublicKeyUrl := fmt.Sprintf("%s/v1/keys/%s/publicKey?%s", t.Player1.Url, url.PathEscape(keyId), query.Encode())
+	player1PublicKeyResponseBody, err := t.httpRequest(player1PublicKeyUrl, "GET", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseBody PublicKeyResponseBody
+	err = json.Unmarshal(player1PublicKeyResponseBody, &responseBody)
+	if err != nil {
+		log.Printf("[PublicKey] failed to json.Unmarshal. error: %s", err)
+		return nil, err
+	}
+
+	return &responseBody, nil
+}
+
+const (
+	DEFAULT_KEY_LIST_LIMIT int = 100
+	MAX_KEY_LIST_LIMIT     int = 1000
+)
+
+type KeyListItem struct {
+	KeyId   string `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Player1 bool   `json:"player1" example:"true"`
+	Player2 bool   `json:"player2" example:"true"`
+	Partial bool   `json:"partial" example:"false"` // true if only one player has a share of the key
+}
+
+type KeyListResponseBody struct {
+	Keys         []KeyListItem `json:"keys"`
+	Total        int           `json:"total" example:"1"`
+	PartialCount int           `json:"partialCount" example:"0"`
+	Offset       int           `json:"offset" example:"0"`
+	Limit        int           `json:"limit" example:"100"`
+}
+
+type playerKeyList struct {
+	KeyIds []string `json:"keyIds"`
+	Total  int      `json:"total"`
+}
+
+func (t *TSMController) ListKeys(offset int, limit int) (*KeyListResponseBody, error) {
+	/*
+		/v1/keys
+		player1, player2 의 key 목록을 합쳐서 한쪽에만 있는 key 를 표시합니다.
+	*/
+	if limit == 0 {
+		limit = DEFAULT_KEY_LIST_LIMIT
+	}
+	if offset < 0 || limit < 0 || limit > MAX_KEY_LIST_LIMIT {
+		return nil, InvalidInputError(fmt.Errorf("offset must be >= 0 and limit must be between 1 and %d", MAX_KEY_LIST_LIMIT))
+	}
+
+	var player1KeyIds, player2KeyIds []string
+	var player1Err, player2Err error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		player1KeyIds, player1Err = t.listPlayerKeys(t.Player1)
+	}()
+	go func() {
+		defer wg.Done()
+		player2KeyIds, player2Err = t.listPlayerKeys(t.Player2)
+	}()
+	wg.Wait()
+	if player1Err != nil {
+		return nil, player1Err
+	}
+	if player2Err != nil {
+		return nil, player2Err
+	}
+
+	itemsByKeyId := make(map[string]*KeyListItem)
+	for _, keyId := range player1KeyIds {
+		itemsByKeyId[keyId] = &KeyListItem{KeyId: keyId, Player1: true}
+	}
+	for _, keyId := range player2KeyIds {
+		if item, ok := itemsByKeyId[keyId]; ok {
+			item.Player2 = true
+			continue
+		}
+		itemsByKeyId[keyId] = &KeyListItem{KeyId: keyId, Player2: true}
+	}
+
+	items := make([]KeyListItem, 0, len(itemsByKeyId))
+	partialCount := 0
+	for _, item := range itemsByKeyId {
+		item.Partial = !(item.Player1 && item.Player2)
+		if item.Partial {
+			partialCount++
+		}
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].KeyId < items[j].KeyId })
+
+	total := len(items)
+	start := min(offset, total)
+	end := min(offset+limit, total)
+	return &KeyListResponseBody{
+		Keys:         items[start:end],
+		Total:        total,
+		PartialCount: partialCount,
+		Offset:       offset,
+		Limit:        limit,
+	}, nil
+}
+
+// listPlayerKeys 는 player 의 모든 key 를 페이지 단위로 가져옵니다.
+func (t *TSMController) listPlayerKeys(player Player) ([]string, error) {
+	var keyIds []string
+	for {
+		playerKeysUrl := fmt.Sprintf("%s/v1/keys?offset=%d&limit=%d", player.Url, len(keyIds), MAX_KEY_LIST_LIMIT)
+		responseBody, err := t.httpRequest(playerKeysUrl, "GET", nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page playerKeyList
+		if err := json.Unmarshal(responseBody, &page); err != nil {
+			log.Printf("[listPlayerKeys] failed to json.Unmarshal. error: %s", err)
+			return nil, err
+		}
+
+		keyIds = append(keyIds, page.KeyIds...)
+		if len(page.KeyIds) == 0 || len(keyIds) >= page.Total {
+			return keyIds, nil
+		}
+	}
+}
+
+func (t *TSMController) RevokeKey(keyId string, devicePublicKey string, revokedBy string, reason string) (*revocation.Revocation, error) {
+	/*
+		DELETE /v1/keys/:keyId
+		분실한 device 의 key 로 더 이상 서명할 수 없도록 player1, player2 의 key share 를 삭제합니다.
+		이미 삭제된 player 가 있어도 (404) 다시 요청할 수 있습니다.
+		잘못된 keyId 로 원본 key 를 지우지 않도록 key metadata 에 devicePublicKey 의 복사본으로 기록된 key 만 삭제합니다.
+	*/
+	log.Printf("[RevokeKey] keyId: %s, revokedBy: %s, reason: %s", keyId, revokedBy, reason)
+	metadata, ok, err := t.KeyMetadata.Get(keyId)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, NotFoundError(fmt.Errorf("no key metadata for keyId: %s", keyId))
+	}
+	if metadata.SourceKeyId == "" {
+		return nil, InvalidInputError(fmt.Errorf("key is not a device copy: %s", keyId))
+	}
+	if metadata.DevicePublicKey != devicePublicKey {
+		return nil, InvalidInputError(fmt.Errorf("devicePublicKey does not match the device of key: %s", keyId))
+	}
+
+	path := fmt.Sprintf("/v1/keys/%s", url.PathEscape(keyId))
+	for _, player := range []Player{t.Player1, t.Player2} {
+		_, err := t.httpRequest(fmt.Sprintf("%s%s", player.Url, path), "DELETE", nil)
+		if err != nil {
+			if errorInfo, ok := err.(*SvcErr); ok && errorInfo.Status == http.StatusNotFound {
+				log.Printf("[RevokeKey] key share already deleted. url: %s", player.Url)
+				continue
+			}
+			return nil, err
+		}
+	}
+
+	record := revocation.Revocation{
+		KeyId:           keyId,
+		DevicePublicKey: devicePublicKey,
+		RevokedBy:       revokedBy,
+		Reason:          reason,
+		RevokedAt:       time.Now(),
+	}
+	if err := t.Revocations.Save(record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+type BackupKeyShareRequestBody struct {
+	PublicKey string `json:"publicKey" binding:"required" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."`
+	Algorithm string `json:"algorithm,omitempty" example:"schnorr"`
+}
+
+// ShareBackup is a share backup of one player encrypted to the operator key.
+type ShareBackup struct {
+	Algorithm      string    `json:"algorithm" example:"RSA-OAEP-256+A256GCM"`
+	KeyId          string    `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	PlayerIndex    string    `json:"playerIndex" example:"1"`
+	RecipientKeyId string    `json:"recipientKeyId" example:"9f2c6a0de1b34c5a8f7e2d1c0b9a8f7e6d5c4b3a291817161514131211100f0e"`
+	EncryptedKey   string    `json:"encryptedKey" example:"base64"`
+	Nonce          string    `json:"nonce" example:"base64"`
+	Ciphertext     string    `json:"ciphertext" example:"base64"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type BackupKeySharesResponseBody struct {
+	KeyId   string       `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Player1 *ShareBackup `json:"player1"`
+	Player2 *ShareBackup `json:"player2"`
+}
+
+func (t *TSMController) BackupKeyShares(keyId string, algorithm string, publicKey string) (*BackupKeySharesResponseBody, error) {
+	/*
+		POST /v1/keys/:keyId/backup
+		player1, player2 의 key share backup 을 operator 의 public key 로 암호화해서 받습니다.
+		복구하려면 두 server share 가 모두 필요하므로 한 player 라도 실패하면 실패로 처리합니다.
+	*/
+	log.Printf("[BackupKeyShares] keyId: %s, algorithm: %s", keyId, algorithm)
+	path := fmt.Sprintf("/v1/keys/%s/backup", url.PathEscape(keyId))
+	requestBody := BackupKeyShareRequestBody{PublicKey: publicKey, Algorithm: algorithm}
+
+	backups := make([]*ShareBackup, 2)
+	for i, player := range []Player{t.Player1, t.Player2} {
+		responseBody, err := t.httpRequest(fmt.Sprintf("%s%s", player.Url, path), "POST", requestBody)
+		if err != nil {
+			return nil, err
+		}
+		var shareBackup ShareBackup
+		if err := json.Unmarshal(responseBody, &shareBackup); err != nil {
+			log.Printf("[BackupKeyShares] failed to json.Unmarshal. error: %s", err)
+			return nil, err
+		}
+		backups[i] = &shareBackup
+	}
+
+	return &BackupKeySharesResponseBody{KeyId: keyId, Player1: backups[0], Player2: backups[1]}, nil
+}
+
+type RecoveryDataRequestBody struct {
+	SessionId    string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	ERSPublicKey string `json:"ersPublicKey" binding:"required" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."`
+	ERSLabel     string `json:"ersLabel,omitempty" example:"abc-tsm-recovery"`
+	Algorithm    string `json:"algorithm,omitempty" example:"schnorr"`
+}
+
+type RecoveryDataResponseBody struct {
+	PartialRecoveryData string `json:"partialRecoveryData" example:"eyJ..."`
+}
+
+func (t *TSMController) ExportRecoveryData(keyId string, algorithm string, ersPublicKey string, ersLabel string) (*tsmutils.RecoveryPackage, error) {
+	/*
+		POST /v1/keys/:keyId/recoveryData
+		player1, player2 가 같은 session 에서 만든 partial recovery data 를 합쳐 ERS recovery data 를 만듭니다.
+		두 player 가 session 에 참여할 때까지 기다리므로 동시에 요청합니다.
+		합친 recovery data 가 key 의 public key 로 검증되어야 반환합니다.
+	*/
+	log.Printf("[ExportRecoveryData] keyId: %s, algorithm: %s, ersLabel: %s", keyId, algorithm, ersLabel)
+	ersRSAPublicKey, err := tsmutils.ParseERSPublicKey(ersPublicKey)
+	if err != nil {
+		return nil, InvalidInputError(err)
+	}
+
+	sessionId := tsm.GenerateSessionID()
+	path := fmt.Sprintf("/v1/keys/%s/recoveryData", url.PathEscape(keyId))
+	requestBody := RecoveryDataRequestBody{SessionId: sessionId, ERSPublicKey: ersPublicKey, ERSLabel: ersLabel, Algorithm: algorithm}
+
+	// 한 player 가 실패하면 다른 player 가 session 을 계속 기다리지 않도록 나머지 요청을 끊습니다.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	players := []Player{t.Player1, t.Player2}
+	partialRecoveryData := make([][]byte, len(players))
+	var firstErr error
+	var failOnce sync.Once
+	var wg sync.WaitGroup
+	for i, player := range players {
+		wg.Add(1)
+		go func(i int, player Player) {
+			defer wg.Done()
+			var err error
+			partialRecoveryData[i], err = t.partialRecoveryData(ctx, fmt.Sprintf("%s%s", player.Url, path), requestBody)
+			if err != nil {
+				// 끊긴 요청의 error 가 아니라 처음 실패한 player 의 error 를 반환합니다.
+				failOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i, player)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	recoveryData, err := tsmutils.FinalizeRecoveryData(algorithm, partialRecoveryData, ersRSAPublicKey, []byte(ersLabel))
+	if err != nil {
+		log.Printf("[ExportRecoveryData] failed to finalize recovery data. error: %s", err)
+		return nil, InvalidRecoveryDataError(err)
+	}
+
+	publicKey, err := t.PublicKey(keyId, algorithm, "")
+	if err != nil {
+		return nil, err
+	}
+	pkixPublicKey, err := base64.StdEncoding.DecodeString(publicKey.PKIX)
+	if err != nil {
+		return nil, InvalidRecoveryDataError(err)
+	}
+
+	recoveryPackage := &tsmutils.RecoveryPackage{
+		KeyId:        keyId,
+		Algorithm:    algorithm,
+		PublicKey:    publicKey.PKIX,
+		ERSPublicKey: ersPublicKey,
+		ERSLabel:     ersLabel,
+		RecoveryData: base64.StdEncoding.EncodeToString(recoveryData),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := recoveryPackage.Validate(pkixPublicKey); err != nil {
+		log.Printf("[ExportRecoveryData] failed to validate recovery data. error: %s", err)
+		return nil, InvalidRecoveryDataError(err)
+	}
+	return recoveryPackage, nil
+}
+
+func (t *TSMController) partialRecoveryData(ctx context.Context, url string, requestBody RecoveryDataRequestBody) ([]byte, error) {
+	responseBodyBytes, err := t.httpRequestContext(ctx, RECOVERY_DATA_TIMEOUT, url, "POST", requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseBody RecoveryDataResponseBody
+	if err := json.Unmarshal(responseBodyBytes, &responseBody); err != nil {
+		log.Printf("[ExportRecoveryData] failed to json.Unmarshal. error: %s", err)
+		return nil, err
+	}
+	partialRecoveryData, err := base64.StdEncoding.DecodeString(responseBody.PartialRecoveryData)
+	if err != nil {
+		return nil, InvalidRecoveryDataError(err)
+	}
+	return partialRecoveryData, nil
+}
+
+func (t *TSMController) GetRevocation(keyId string) (*revocation.Revocation, error) {
+	record, ok, err := t.Revocations.Get(keyId)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, NotFoundError(fmt.Errorf("key is not revoked: %s", keyId))
+	}
+	return record, nil
+}
+
+// RecordSessionEvent stores a completion event posted by a player.
+func (t *TSMController) RecordSessionEvent(event sessiontracker.Event) (*sessiontracker.Session, error) {
+	log.Printf("[RecordSessionEvent] sessionId: %s, playerIndex: %s, operation: %s, status: %s", event.SessionId, event.PlayerIndex, event.Operation, event.Status)
+	previous, known := t.Sessions.Get(event.SessionId)
+	result, err := t.Sessions.Record(event)
+	if err != nil {
+		return nil, InvalidInputError(err)
+	}
+	// 재시도된 callback 이나 appserver 가 시작하지 않은 session 은 시간을 알 수 없으므로 기록하지 않습니다.
+	if _, reported := previous.Players[event.PlayerIndex]; known && !reported {
+		t.metrics.Observe(event.Operation, event.Status, event.PlayerIndex, time.Since(previous.CreatedAt))
+	}
+	if err := t.recordKeyMetadata(result); err != nil {
+		// player 가 callback 을 재시도하면 다시 저장합니다.
+		log.Printf("[RecordSessionEvent] failed to save key metadata. sessionId: %s, error: %v", result.SessionId, err)
+		return nil, err
+	}
+	return &result, nil
+}
+
+// recordKeyMetadata saves the metadata of the key a generate, copy or import session produced, once the session succeeds.
+func (t *TSMController) recordKeyMetadata(session sessiontracker.Session) error {
+	if session.Status == sessiontracker.PENDING {
+		return nil
+	}
+	metadata, ok := t.pendingKeys.Take(session.SessionId)
+	if !ok || session.Status != sessiontracker.SUCCEEDED {
+		return nil
+	}
+	pending := metadata
+
+	metadata.KeyId = session.KeyId
+	metadata.CreatedAt = session.UpdatedAt.UTC()
+	// algorithm, curve 가 요청에 없으면 player 의 기본값이 사용되므로 생성된 public key 로 확인합니다.
+	publicKey, err := t.PublicKey(session.KeyId, metadata.Algorithm, "")
+	if err != nil {
+		log.Printf("[WARN] failed to get public key of %s. metadata keeps the requested algorithm and curve. error: %v", session.KeyId, err)
+	} else {
+		metadata.Algorithm = publicKey.Algorithm
+		metadata.PublicKey = publicKey.PKIX
+	}
+	if metadata.PublicKey != "" {
+		if curve, err := tsmutils.PKIXCurve(metadata.PublicKey); err == nil {
+			metadata.Curve = curve
+		}
+	}
+
+	if err := t.KeyMetadata.Save(metadata); err != nil {
+		t.pendingKeys.Add(pending)
+		return err
+	}
+	log.Printf("[recordKeyMetadata] keyId: %s, userId: %s, operation: %s", metadata.KeyId, metadata.UserId, metadata.Operation)
+	return nil
+}
+
+// GetKeyMetadata returns the metadata of a key created through the appserver.
+func (t *TSMController) GetKeyMetadata(keyId string) (*keymetadata.KeyMetadata, error) {
+	metadata, ok, err := t.KeyMetadata.Get(keyId)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, NotFoundError(fmt.Errorf("no metadata for key: %s", keyId))
+	}
+	return metadata, nil
+}
+
+type KeyLineageItem struct {
+	keymetadata.LineageNode
+	Revocation *revocation.Revocation `json:"revocation,omitempty"` // set if the key has been revoked
+}
+
+type KeyLineageResponseBody struct {
+	RootKeyId string           `json:"rootKeyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Keys      []KeyLineageItem `json:"keys"` // root first, then copies level by level
+	Truncated bool             `json:"truncated" example:"false"`
+}
+
+// KeyLineage returns the original key of keyId and all of its copies with their devices and revocations,
+// so that the copies of a revoked or compromised key can be handled together.
+func (t *TSMController) KeyLineage(keyId string) (*KeyLineageResponseBody, error) {
+	lineage, found, err := keymetadata.FindLineage(t.KeyMetadata, keyId)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, NotFoundError(fmt.Errorf("no lineage for key: %s", keyId))
+	}
+
+	items := make([]KeyLineageItem, 0, len(lineage.Keys))
+	for _, node := range lineage.Keys {
+		record, ok, err := t.Revocations.Get(node.KeyId)
+		if err != nil {
+			return nil, err
+		}
+		item := KeyLineageItem{LineageNode: node}
+		if ok {
+			item.Revocation = record
+		}
+		items = append(items, item)
+	}
+	return &KeyLineageResponseBody{RootKeyId: lineage.RootKeyId, Keys: items, Truncated: lineage.Truncated}, nil
+}
+
+// FindKeyMetadata returns the keys of a user and/or device. At least one of them is required.
+func (t *TSMController) FindKeyMetadata(userId string, devicePublicKey string) ([]keymetadata.KeyMetadata, error) {
+	if userId == "" && devicePublicKey == "" {
+		return nil, InvalidInputError(fmt.Errorf("userId or devicePublicKey is required"))
+	}
+	return t.KeyMetadata.Find(keymetadata.Query{UserId: userId, DevicePublicKey: devicePublicKey})
+}
+
+// GetSession returns the result of a session correlated over both players.
+func (t *TSMController) GetSession(sessionId string) (*sessiontracker.Session, error) {
+	result, ok := t.Sessions.Get(sessionId)
+	if !ok {
+		return nil, NotFoundError(fmt.Errorf("session not found: %s", sessionId))
+	}
+	return &result, nil
+}
+
+const (
+	CANCEL_CANCELLED string = "cancelled"
+	CANCEL_FINISHED  string = "finished"
+	CANCEL_NOT_FOUND string = "notFound"
+)
+
+type CancelSessionResponseBody struct {
+	SessionId string `json:"sessionId" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	Player1   string `json:"player1" example:"cancelled"` // cancelled, finished or notFound
+	Player2   string `json:"player2" example:"cancelled"` // cancelled, finished or notFound
+}
+
+func (t *TSMController) CancelSession(sessionId string) (*CancelSessionResponseBody, error) {
+	/*
+		POST /v1/sessions/:sessionId/cancel
+		player1, player2 의 session 을 동시에 취소합니다.
+		preSign 은 player1 에서만 실행되므로 한쪽 player 에 session 이 없을 수 있습니다.
+	*/
+	log.Printf("[CancelSession] sessionId: %s", sessionId)
+	path := fmt.Sprintf("/v1/sessions/%s/cancel", url.PathEscape(sessionId))
+	players := []Player{t.Player1, t.Player2}
+	results := make([]string, len(players))
+	errs := make([]error, len(players))
+
+	var wg sync.WaitGroup
+	for i, player := range players {
+		wg.Add(1)
+		go func(i int, player Player) {
+			defer wg.Done()
+			_, err := t.httpRequest(fmt.Sprintf("%s%s", player.Url, path), "POST", nil)
+			results[i], errs[i] = cancelResult(err)
+		}(i, player)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	if results[0] == CANCEL_NOT_FOUND && results[1] == CANCEL_NOT_FOUND {
+		return nil, NotFoundError(fmt.Errorf("session not found: %s", sessionId))
+	}
+
+	return &CancelSessionResponseBody{SessionId: sessionId, Player1: results[0], Player2: results[1]}, nil
+}
+
+// cancelResult 는 player 의 취소 응답을 결과로 변환합니다. 404, 409 는 error 가 아닙니다.
+func cancelResult(err error) (string, error) {
+	if err == nil {
+		return CANCEL_CANCELLED, nil
+	}
+	if errorInfo, ok := err.(*SvcErr); ok {
+		switch errorInfo.Status {
+		case http.StatusNotFound:
+			return CANCEL_NOT_FOUND, nil
+		case http.StatusConflict:
+			return CANCEL_FINISHED, nil
+		}
+	}
+	return "", err
+}
+
+// requestPlayers 는 players 에게 같은 요청을 동시에 보내고 모든 응답을 기다립니다.
+func (t *TSMController) requestPlayers(method string, path string, sessionId string, requestBody any, players ...Player) error {
+	requests := make([]playerRequest, len(players))
+	for i, player := range players {
+		requests[i] = playerRequest{player: player, body: requestBody}
+	}
+	return t.requestEachPlayer(method, path, sessionId, requests...)
+}
+
+// playerRequest 는 한 player 에게 보낼 요청 body 입니다.
+type playerRequest struct {
+	player Player
+	body   any
+}
+
+// requestEachPlayer 는 player 마다 다른 body 로 동시에 요청하고 모든 응답을 기다립니다.
+// 실패한 player 가 있으면 session 을 시작한 player 가 상대를 기다리지 않도록 모든 player 의 session 을 취소하고 첫번째 error 를 반환합니다.
+func (t *TSMController) requestEachPlayer(method string, path string, sessionId string, requests ...playerRequest) error {
+	errs := make([]error, len(requests))
+
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func(i int, request playerRequest) {
+			defer wg.Done()
+			_, errs[i] = t.httpRequest(fmt.Sprintf("%s%s", request.player.Url, path), method, request.body)
+		}(i, request)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			players := make([]Player, len(requests))
+			for i, request := range requests {
+				players[i] = request.player
+			}
+			t.cancelPlayers(sessionId, players...)
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelPlayers 는 시작에 실패한 session 을 취소합니다.
+// 응답을 받지 못한 player 도 session 을 시작했을 수 있으므로 모든 player 에 보내고, 결과는 기록만 합니다.
+func (t *TSMController) cancelPlayers(sessionId string, players ...Player) {
+	path := fmt.Sprintf("/v1/sessions/%s/cancel", url.PathEscape(sessionId))
+
+	var wg sync.WaitGroup
+	for _, player := range players {
+		wg.Add(1)
+		go func(player Player) {
+			defer wg.Done()
+			_, err := t.httpRequest(fmt.Sprintf("%s%s", player.Url, path), "POST", nil)
+			result, err := cancelResult(err)
+			if err != nil {
+				log.Printf("[cancelPlayers] failed to cancel session. sessionId: %s, url: %s, error: %v", sessionId, player.Url, err)
+				return
+			}
+			log.Printf("[cancelPlayers] sessionId: %s, url: %s, result: %s", sessionId, player.Url, result)
+		}(player)
+	}
+	wg.Wait()
+}
+
+func (t *TSMController) httpRequest(url string, method string, requestBody any) ([]byte, error) {
+	return t.httpRequestContext(context.Background(), REQUEST_TIMEOUT, url, method, requestBody)
+}
+
+// httpRequestContext 는 ctx 가 취소되거나 timeout 이 지나면 요청을 중단합니다.
+func (t *TSMController) httpRequestContext(ctx context.Context, timeout time.Duration, url string, method string, requestBody any) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	requestedAt := time.Now()
+	body, err := t.sendRequest(ctx, url, method, requestBody)
+
+	// url 에는 keyId 가 포함될 수 있으므로 player 단위로 기록합니다.
+	playerIndex, playerUrl := t.playerOf(url)
+	requestOutcome := outcome(err)
+	if svcErr, ok := err.(*SvcErr); ok && svcErr.Text == NODE_UNAVAILABLE {
+		requestOutcome = metrics.UNAVAILABLE
+	}
+	t.metrics.ObservePlayerRequest(playerIndex, playerUrl, method, requestOutcome, time.Since(requestedAt))
+	return body, err
+}
+
+func (t *TSMController) playerOf(url string) (string, string) {
+	for playerIndex, player := range map[string]Player{"1": t.Player1, "2": t.Player2} {
+		if strings.HasPrefix(url, player.Url+"/") {
+			return playerIndex, player.Url
+		}
+	}
+	return "", ""
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return metrics.FAILED
+	}
+	return metrics.SUCCEEDED
+}
+
+func (t *TSMController) sendRequest(ctx context.Context, url string, method string, requestBody any) ([]byte, error) {
 	var requestBodyBytes []byte
 	if method == "POST" {
 		var err error
 		requestBodyBytes, err = json.Marshal(requestBody)
 		if err != nil {
 			log.Printf("[httpRequest] failed to json.Marshal. error: %s", err)
+			return nil, err
 		}
 	}
 
 	log.Printf("[httpRequest] url: %s, method: %s, requestBody: %s", url, method, string(requestBodyBytes))
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(requestBodyBytes))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(requestBodyBytes))
 	if err != nil {
 		log.Printf("[httpRequest] failed to http.NewRequest. error: %s", err.Error())
+		return nil, err
 	}
 	req.Header.Set("User-Agent", "ABC")
 	req.Header.Set("Content-Type", "application/json")
+	if err := t.signer.Sign(req, requestBodyBytes); err != nil {
+		log.Printf("[httpRequest] failed to sign request. error: %s", err.Error())
+		return nil, err
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := t.httpClient.Do(req)
 	if err != nil {
-		log.Printf("[httpRequest] failed to http.NewRequest. error: %s", err.Error())
+		log.Printf("[httpRequest] failed to client.Do. error: %s", err.Error())
+		return nil, PlayerUnavailableError(url, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("[httpRequest] failed to http.NewRequest. error: %s", err.Error())
+		log.Printf("[httpRequest] failed to io.ReadAll. error: %s", err.Error())
+		return nil, PlayerUnavailableError(url, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("[httpRequest] url: %s, status: %d, body: %s", url, resp.StatusCode, string(body))
+		return nil, PlayerError(url, resp.StatusCode, body)
 	}
 
-	return body
+	return body, nil
 }
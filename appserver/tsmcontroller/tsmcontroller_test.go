@@ -0,0 +1,74 @@
+package tsmcontroller
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahnlabio/tsm-appserver/auth"
+	"github.com/ahnlabio/tsm-appserver/keymetadata"
+	"github.com/ahnlabio/tsm-appserver/metrics"
+	"github.com/ahnlabio/tsm-appserver/revocation"
+	"github.com/ahnlabio/tsm-appserver/sessiontracker"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newTestController(t *testing.T, player1 string, player2 string) *TSMController {
+	t.Helper()
+	signer, err := auth.NewSigner(auth.NONE, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := sessiontracker.NewTracker()
+	serviceMetrics := metrics.New(prometheus.NewRegistry(), sessions.InFlight)
+	return NewTSMController(Player{Url: player1}, Player{Url: player2}, revocation.NewMemoryStore(), keymetadata.NewMemoryStore(), sessions, serviceMetrics, &http.Client{}, signer)
+}
+
+func TestExportRecoveryDataCancelsOtherPlayerOnFailure(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"text":"POLICY_DENIED","message":"recipient public key is not allowed"}}`))
+	}))
+	defer player1.Close()
+	// player2 는 player1 이 session 에 참여할 때까지 기다립니다.
+	// 요청 body 를 다 읽어야 server 가 연결이 끊긴 것을 알 수 있습니다.
+	cancelled := make(chan struct{})
+	player2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusGatewayTimeout)
+		}
+	}))
+	defer player2.Close()
+
+	controller := newTestController(t, player1.URL, player2.URL)
+	_, err = controller.ExportRecoveryData("k", "schnorr", base64.StdEncoding.EncodeToString(der), "")
+	svcErr, ok := err.(*SvcErr)
+	if !ok || svcErr.Text != POLICY_DENIED {
+		t.Fatalf("error = %v, want the %s error of player1", err, POLICY_DENIED)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("the request to player2 was not cancelled")
+	}
+}
@@ -0,0 +1,35 @@
+package tsmutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+)
+
+// ValidatePlayerPublicKey checks the public key of the dynamic player (player 0) before a session is requested.
+// The key must be a base64 encoded DER SubjectPublicKeyInfo holding a P-256 point on the curve.
+func ValidatePlayerPublicKey(publicKey string) error {
+	der, err := base64.StdEncoding.Strict().DecodeString(publicKey)
+	if err != nil {
+		return fmt.Errorf("public key is not base64: %w", err)
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return fmt.Errorf("public key is not a valid SubjectPublicKeyInfo: %w", err)
+	}
+
+	ecdsaPublicKey, ok := parsed.(*ecdsa.PublicKey)
+	if !ok || ecdsaPublicKey.Curve != elliptic.P256() {
+		return fmt.Errorf("public key is not a P-256 key")
+	}
+
+	// ECDH 변환은 point 가 curve 위에 있고 무한원점이 아닌지 확인합니다.
+	if _, err := ecdsaPublicKey.ECDH(); err != nil {
+		return fmt.Errorf("public key point is not on P-256: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package tsmutils
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+)
+
+var (
+	oidEd25519     = asn1.ObjectIdentifier{1, 3, 101, 112}
+	oidEd448       = asn1.ObjectIdentifier{1, 3, 101, 113}
+	oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// controller 의 KEY_POLICY 와 같은 curve 이름
+var namedCurves = map[string]string{
+	"1.3.132.0.10":        "secp256k1",
+	"1.2.840.10045.3.1.7": "P-256",
+}
+
+// PKIXCurve returns the curve name of a base64 encoded PKIX (SubjectPublicKeyInfo) public key, as returned by the players.
+func PKIXCurve(publicKey string) (string, error) {
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("public key is not base64: %w", err)
+	}
+	var subjectPublicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &subjectPublicKeyInfo); err != nil {
+		return "", fmt.Errorf("public key is not a valid SubjectPublicKeyInfo: %w", err)
+	}
+
+	algorithm := subjectPublicKeyInfo.Algorithm
+	switch {
+	case algorithm.Algorithm.Equal(oidEd25519):
+		return "ED-25519", nil
+	case algorithm.Algorithm.Equal(oidEd448):
+		return "ED-448", nil
+	case algorithm.Algorithm.Equal(oidECPublicKey):
+		var namedCurve asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, &namedCurve); err != nil {
+			return "", fmt.Errorf("public key has no named curve: %w", err)
+		}
+		if curveName, ok := namedCurves[namedCurve.String()]; ok {
+			return curveName, nil
+		}
+		return "", fmt.Errorf("unsupported curve: %s", namedCurve)
+	}
+	return "", fmt.Errorf("unsupported public key algorithm: %s", algorithm.Algorithm)
+}
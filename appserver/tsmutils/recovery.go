@@ -0,0 +1,85 @@
+package tsmutils
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
+)
+
+// RecoveryPackage is the ERS recovery data of a key with everything needed to validate it offline.
+// Whoever holds the ERS private key and the label can recover the private key from it with SchnorrRecoverPrivateKey
+// (or ECDSARecoverPrivateKey), without the TSM.
+type RecoveryPackage struct {
+	KeyId        string    `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm    string    `json:"algorithm" example:"schnorr"`
+	PublicKey    string    `json:"publicKey" example:"MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="` // base64 PKIX public key of the key
+	ERSPublicKey string    `json:"ersPublicKey" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."`           // base64 SubjectPublicKeyInfo
+	ERSLabel     string    `json:"ersLabel" example:"abc-tsm-recovery"`
+	RecoveryData string    `json:"recoveryData" example:"eyJ..."` // base64
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ParseERSPublicKey parses a base64 encoded SubjectPublicKeyInfo of an RSA key.
+func ParseERSPublicKey(publicKey string) (*rsa.PublicKey, error) {
+	der, err := base64.StdEncoding.Strict().DecodeString(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("ERS public key is not base64: %w", err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("ERS public key is not a valid SubjectPublicKeyInfo: %w", err)
+	}
+	rsaPublicKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("ERS public key is not an RSA key")
+	}
+	return rsaPublicKey, nil
+}
+
+// ERSKeyFingerprint returns the hex sha256 of the ERS SubjectPublicKeyInfo, as configured in ERS_RECIPIENT_FINGERPRINTS.
+func ERSKeyFingerprint(publicKey string) (string, error) {
+	der, err := base64.StdEncoding.Strict().DecodeString(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// FinalizeRecoveryData combines the partial recovery data of the players.
+func FinalizeRecoveryData(algorithm string, partialRecoveryData [][]byte, ersPublicKey *rsa.PublicKey, ersLabel []byte) ([]byte, error) {
+	switch algorithm {
+	case "", "schnorr":
+		return tsm.SchnorrFinalizeRecoveryData(partialRecoveryData, ersPublicKey, ersLabel)
+	case "ecdsa":
+		return tsm.ECDSAFinalizeRecoveryData(partialRecoveryData, ersPublicKey, ersLabel)
+	}
+	return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
+}
+
+// Validate checks that the recovery data can recover the private key of pkixPublicKey.
+// pkixPublicKey should be obtained independently, e.g. from Schnorr().PublicKey, not taken from the package.
+func (p *RecoveryPackage) Validate(pkixPublicKey []byte) error {
+	ersPublicKey, err := ParseERSPublicKey(p.ERSPublicKey)
+	if err != nil {
+		return err
+	}
+	recoveryData, err := base64.StdEncoding.DecodeString(p.RecoveryData)
+	if err != nil {
+		return fmt.Errorf("recovery data is not base64: %w", err)
+	}
+
+	switch p.Algorithm {
+	case "", "schnorr":
+		return tsm.SchnorrValidateRecoveryData(recoveryData, pkixPublicKey, ersPublicKey, []byte(p.ERSLabel))
+	case "ecdsa":
+		return tsm.ECDSAValidateRecoveryData(recoveryData, pkixPublicKey, ersPublicKey, []byte(p.ERSLabel))
+	}
+	return fmt.Errorf("unsupported algorithm: %s", p.Algorithm)
+}
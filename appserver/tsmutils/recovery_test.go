@@ -0,0 +1,96 @@
+package tsmutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func marshalPublicKey(t *testing.T, publicKey any) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func TestParseERSPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseERSPublicKey(marshalPublicKey(t, &rsaKey.PublicKey)); err != nil {
+		t.Errorf("RSA key: %v", err)
+	}
+	tests := map[string]string{
+		"not base64": "not base64!",
+		"not a key":  base64.StdEncoding.EncodeToString([]byte("not a key")),
+		"EC key":     marshalPublicKey(t, &ecKey.PublicKey),
+	}
+	for name, publicKey := range tests {
+		if _, err := ParseERSPublicKey(publicKey); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestERSKeyFingerprint(t *testing.T) {
+	der := []byte("public key")
+	sum := sha256.Sum256(der)
+	fingerprint, err := ERSKeyFingerprint(base64.StdEncoding.EncodeToString(der))
+	if err != nil || fingerprint != hex.EncodeToString(sum[:]) {
+		t.Errorf("ERSKeyFingerprint = %s, %v", fingerprint, err)
+	}
+	if _, err := ERSKeyFingerprint("not base64!"); err == nil {
+		t.Error("expected an error for a key that is not base64")
+	}
+}
+
+func TestRecoveryPackageValidateRejects(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkixPublicKey, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := RecoveryPackage{
+		KeyId:        "k",
+		Algorithm:    "ecdsa",
+		ERSPublicKey: marshalPublicKey(t, &rsaKey.PublicKey),
+		ERSLabel:     "abc-tsm-recovery",
+		RecoveryData: base64.StdEncoding.EncodeToString([]byte("recovery data")),
+	}
+
+	tests := map[string]func(p *RecoveryPackage){
+		"invalid ERS key":       func(p *RecoveryPackage) { p.ERSPublicKey = "not base64!" },
+		"recovery not base64":   func(p *RecoveryPackage) { p.RecoveryData = "not base64!" },
+		"unsupported algorithm": func(p *RecoveryPackage) { p.Algorithm = "rsa" },
+		"garbage ecdsa data":    func(p *RecoveryPackage) {},
+		"garbage schnorr data":  func(p *RecoveryPackage) { p.Algorithm = "schnorr" },
+	}
+	for name, change := range tests {
+		p := valid
+		change(&p)
+		if err := p.Validate(pkixPublicKey); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -4,9 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/hex"
-	"fmt"
 
-	"github.com/ahnlabio/tsm-appserver/config"
 	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
 )
 
@@ -53,39 +51,3 @@ func GetClientFromConfig(config *tsm.Configuration) *tsm.Client {
 	}
 	return client
 }
-
-func KeyListing(configs []*tsm.Configuration) {
-	clients := make([]*tsm.Client, len(configs))
-	for i, config := range configs {
-		var err error
-		if clients[i], err = tsm.NewClient(config); err != nil {
-			panic(err)
-		}
-	}
-
-	ctx := context.Background()
-	for idx, client := range clients {
-		keyIDs, err := client.KeyManagement().ListKeys(ctx)
-		if err != nil {
-			panic(err)
-		}
-		fmt.Printf("node: %d, keyIDs %v\n", idx, keyIDs)
-	}
-}
-
-func GetClients() []*tsm.Client {
-	appConfig := config.GetConfig()
-	configs := []*tsm.Configuration{
-		tsm.Configuration{URL: appConfig.Node1Url}.WithAPIKeyAuthentication(appConfig.Node1ApiKey),
-		tsm.Configuration{URL: appConfig.Node2Url}.WithAPIKeyAuthentication(appConfig.Node2ApiKey),
-	}
-
-	clients := make([]*tsm.Client, len(configs))
-	for i, config := range configs {
-		var err error
-		if clients[i], err = tsm.NewClient(config); err != nil {
-			panic(err)
-		}
-	}
-	return clients
-}
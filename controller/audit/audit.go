@@ -0,0 +1,300 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	STARTED   string = "started"
+	SUCCEEDED string = "succeeded"
+	FAILED    string = "failed"
+	CANCELLED string = "cancelled"
+)
+
+// operations that are not sessions. session operations use the session operation names (generateKey, copyKey, reshareKey, importKey, preSign).
+const (
+	PARTIAL_SIGN   string = "partialSign"
+	DELETE_KEY     string = "deleteKey"
+	CANCEL_SESSION string = "cancelSession"
+	BACKUP_SHARE   string = "backupKeyShare"
+	RECOVERY_DATA  string = "generateRecoveryData"
+)
+
+// prevHash of the first entry
+const GENESIS_HASH string = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Entry is one line of the audit log.
+// Hash is an HMAC over every other field including PrevHash, so changing, removing or reordering
+// an entry breaks the chain from that entry on, and only a holder of AUDIT_HMAC_SECRET can rebuild it.
+// Removing the last entries keeps the chain valid, so the head (seq and hash) is also written
+// to the process log after every append and must be compared with the last entry.
+type Entry struct {
+	Seq               uint64    `json:"seq" example:"1"`
+	Time              time.Time `json:"time"`
+	SessionId         string    `json:"sessionId,omitempty" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	KeyId             string    `json:"keyId,omitempty" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Operation         string    `json:"operation" example:"partialSign"`
+	Caller            string    `json:"caller" example:"appserver"`
+	MessageHashDigest string    `json:"messageHashDigest,omitempty"` // hex(sha256(message hash)) of a partial signature
+	Outcome           string    `json:"outcome" example:"succeeded"`
+	Error             string    `json:"error,omitempty"`
+	PrevHash          string    `json:"prevHash"`
+	Hash              string    `json:"hash"`
+}
+
+// Logger records key operations.
+type Logger interface {
+	Append(entry Entry) error
+	Export(w io.Writer) error
+}
+
+// ComputeHash returns hex(HMAC-SHA256(secret, json of the entry without Hash)).
+func ComputeHash(secret []byte, entry Entry) string {
+	entry.Hash = ""
+	data, _ := json.Marshal(entry)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseSecret decodes AUDIT_HMAC_SECRET. The chain can't be verified without it, so it is required.
+func ParseSecret(secret string) ([]byte, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("AUDIT_HMAC_SECRET is required")
+	}
+	secretBytes, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("AUDIT_HMAC_SECRET must be base64: %w", err)
+	}
+	return secretBytes, nil
+}
+
+// Digest returns hex(sha256(data)). Used to record which message was signed without storing it.
+func Digest(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// FileLog appends entries as JSON lines to a file.
+type FileLog struct {
+	mu       sync.Mutex
+	path     string
+	secret   []byte
+	file     *os.File
+	size     int64 // end of the last complete entry
+	seq      uint64
+	lastHash string
+}
+
+// OpenFileLog opens or creates the log and verifies the existing chain so new entries continue it.
+// An unterminated last line, left by a crash while writing, is completed with a newline if it is an entry
+// that continues the chain, and removed otherwise. A removed entry was never acknowledged.
+func OpenFileLog(path string, secret []byte) (*FileLog, error) {
+	l := &FileLog{path: path, secret: secret, lastHash: GENESIS_HASH}
+
+	unterminated := false
+	existing, err := os.Open(path)
+	if err == nil {
+		last, size, err := verify(existing, secret, true)
+		if err == nil && size > 0 {
+			unterminated, err = endsWithoutNewline(existing, size)
+		}
+		existing.Close()
+		if err != nil {
+			return nil, fmt.Errorf("existing audit log is invalid: %w", err)
+		}
+		l.size = size
+		if last != nil {
+			l.seq = last.Seq
+			l.lastHash = last.Hash
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := file.Stat(); err == nil && info.Size() > l.size {
+		log.Printf("[WARN] [audit] removing an incomplete entry at the end of %s. offset: %d", path, l.size)
+		if err := file.Truncate(l.size); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	if unterminated {
+		log.Printf("[WARN] [audit] completing the last entry of %s with a newline. seq: %d", path, l.seq)
+		if _, err := file.Write([]byte("\n")); err != nil {
+			file.Close()
+			return nil, err
+		}
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return nil, err
+		}
+		l.size++
+	}
+	l.file = file
+	log.Printf("[audit] head. seq: %d, hash: %s", l.seq, l.lastHash)
+	return l, nil
+}
+
+// endsWithoutNewline reports whether the byte before offset is not a newline.
+func endsWithoutNewline(file *os.File, offset int64) (bool, error) {
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, offset-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+// Append fills Seq, Time, PrevHash and Hash and writes the entry to disk before returning.
+func (l *FileLog) Append(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq = l.seq + 1
+	entry.Time = time.Now().UTC()
+	entry.PrevHash = l.lastHash
+	entry.Hash = ComputeHash(l.secret, entry)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	_, err = l.file.Write(line)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		// 일부만 쓰인 entry 가 남으면 다음 시작 때 chain 검증이 실패하므로 마지막 entry 끝으로 되돌립니다.
+		if truncateErr := l.file.Truncate(l.size); truncateErr != nil {
+			log.Printf("[ERROR] [audit] failed to truncate a partially written entry. offset: %d, error: %v", l.size, truncateErr)
+		}
+		return err
+	}
+
+	l.size += int64(len(line))
+	l.seq = entry.Seq
+	l.lastHash = entry.Hash
+	// 파일 밖에 head 를 남겨 마지막 entry 를 지워도 알 수 있게 합니다.
+	log.Printf("[audit] head. seq: %d, hash: %s", l.seq, l.lastHash)
+	return nil
+}
+
+// Head returns the seq and hash of the last entry.
+func (l *FileLog) Head() (uint64, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.seq, l.lastHash
+}
+
+// Export writes the log up to the last complete entry.
+// 느린 client 때문에 Append 가 막히지 않도록 lock 은 크기를 확인할 때만 잡습니다.
+func (l *FileLog) Export(w io.Writer) error {
+	l.mu.Lock()
+	size := l.size
+	l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, io.LimitReader(file, size))
+	return err
+}
+
+// VerifyError points at the first entry that breaks the chain.
+type VerifyError struct {
+	Line   int
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// Verify checks the whole chain and returns the last entry, or nil if the log is empty.
+// The caller should compare it with the head published in the process log to detect removed entries.
+func Verify(r io.Reader, secret []byte) (*Entry, error) {
+	last, _, err := verify(r, secret, false)
+	return last, err
+}
+
+var errIncomplete = errors.New("incomplete entry")
+
+// verify returns the last entry and the offset after it.
+// If allowTornTail is set, an unterminated last line, as left by an interrupted write, is not an error.
+// It is kept if it is a complete entry that continues the chain, and left out of the offset otherwise.
+func verify(r io.Reader, secret []byte, allowTornTail bool) (*Entry, int64, error) {
+	reader := bufio.NewReader(r)
+
+	var last *Entry
+	var offset int64
+	prevHash := GENESIS_HASH
+	line := 0
+	for {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) == 0 {
+				break
+			}
+			if !allowTornTail {
+				return nil, 0, &VerifyError{Line: line + 1, Reason: errIncomplete.Error()}
+			}
+			entry, err := checkEntry(secret, data, line+1, prevHash)
+			if err != nil {
+				return last, offset, nil
+			}
+			return entry, offset + int64(len(data)), nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		line++
+
+		entry, err := checkEntry(secret, data, line, prevHash)
+		if err != nil {
+			return nil, 0, err
+		}
+		prevHash = entry.Hash
+		offset += int64(len(data))
+		last = entry
+	}
+	return last, offset, nil
+}
+
+// checkEntry parses a line and checks that it is the entry at line that follows prevHash.
+func checkEntry(secret []byte, data []byte, line int, prevHash string) (*Entry, error) {
+	var entry Entry
+	if err := json.Unmarshal(bytes.TrimSuffix(data, []byte("\n")), &entry); err != nil {
+		return nil, &VerifyError{Line: line, Reason: fmt.Sprintf("invalid json: %v", err)}
+	}
+	if entry.Seq != uint64(line) {
+		return nil, &VerifyError{Line: line, Reason: fmt.Sprintf("expected seq %d, got %d", line, entry.Seq)}
+	}
+	if entry.PrevHash != prevHash {
+		return nil, &VerifyError{Line: line, Reason: "prevHash does not match the previous entry"}
+	}
+	if !hmac.Equal([]byte(ComputeHash(secret, entry)), []byte(entry.Hash)) {
+		return nil, &VerifyError{Line: line, Reason: "hash does not match the entry"}
+	}
+	return &entry, nil
+}
@@ -0,0 +1,226 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func openTestLog(t *testing.T, path string) *FileLog {
+	t.Helper()
+	l, err := OpenFileLog(path, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.file.Close() })
+	return l
+}
+
+func writeEntries(t *testing.T, path string, count int) {
+	t.Helper()
+	l := openTestLog(t, path)
+	for i := 0; i < count; i++ {
+		if err := l.Append(Entry{SessionId: "s", Operation: "generateKey", Caller: "appserver", Outcome: SUCCEEDED}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.file.Close()
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	return lines[:len(lines)-1]
+}
+
+func TestAppendAndVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeEntries(t, path, 3)
+
+	// 다시 열면 chain 을 이어서 씁니다.
+	l := openTestLog(t, path)
+	if err := l.Append(Entry{SessionId: "s", Operation: "copyKey", Outcome: FAILED}); err != nil {
+		t.Fatal(err)
+	}
+	seq, head := l.Head()
+
+	var exported bytes.Buffer
+	if err := l.Export(&exported); err != nil {
+		t.Fatal(err)
+	}
+	last, err := Verify(&exported, testSecret)
+	if err != nil {
+		t.Fatalf("Verify error: %v", err)
+	}
+	if last.Seq != 4 || seq != 4 || last.Hash != head {
+		t.Errorf("last entry = %d %s, head = %d %s", last.Seq, last.Hash, seq, head)
+	}
+}
+
+func TestVerifyEmptyLog(t *testing.T) {
+	last, err := Verify(strings.NewReader(""), testSecret)
+	if err != nil || last != nil {
+		t.Errorf("Verify(empty) = %v, %v", last, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeEntries(t, path, 3)
+	lines := readLines(t, path)
+
+	changed := make([]string, len(lines))
+	copy(changed, lines)
+	var entry Entry
+	json.Unmarshal([]byte(changed[1]), &entry)
+	entry.Caller = "attacker"
+	data, _ := json.Marshal(entry)
+	changed[1] = string(data) + "\n"
+
+	// secret 없이 hash 를 다시 계산해도 검증에 실패합니다.
+	rehashed := make([]string, len(lines))
+	copy(rehashed, lines)
+	entry.Hash = ComputeHash([]byte("guessed secret"), entry)
+	data, _ = json.Marshal(entry)
+	rehashed[1] = string(data) + "\n"
+
+	tests := map[string]struct {
+		log  string
+		line int
+	}{
+		"changed entry":   {strings.Join(changed, ""), 2},
+		"rehashed entry":  {strings.Join(rehashed, ""), 2},
+		"removed entry":   {lines[0] + lines[2], 2},
+		"reordered":       {lines[1] + lines[0] + lines[2], 1},
+		"incomplete line": {lines[0] + lines[1][:10], 2},
+	}
+	for name, test := range tests {
+		_, err := Verify(strings.NewReader(test.log), testSecret)
+		var verifyErr *VerifyError
+		if !errors.As(err, &verifyErr) {
+			t.Errorf("%s: error = %v, want a VerifyError", name, err)
+			continue
+		}
+		if verifyErr.Line != test.line {
+			t.Errorf("%s: line = %d, want %d", name, verifyErr.Line, test.line)
+		}
+	}
+
+	if _, err := Verify(strings.NewReader(strings.Join(lines, "")), []byte("other secret")); err == nil {
+		t.Error("expected an error for another secret")
+	}
+}
+
+func TestOpenFileLogRemovesIncompleteEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeEntries(t, path, 2)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"seq":3,"sessionId"`)
+	file.Close()
+
+	l := openTestLog(t, path)
+	if seq, _ := l.Head(); seq != 2 {
+		t.Errorf("head seq = %d, want 2", seq)
+	}
+	if err := l.Append(Entry{SessionId: "s", Outcome: SUCCEEDED}); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if last, err := Verify(file, testSecret); err != nil || last.Seq != 3 {
+		t.Errorf("Verify after recovery = %v, %v", last, err)
+	}
+}
+
+func TestOpenFileLogRejectsTamperedLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeEntries(t, path, 2)
+	lines := readLines(t, path)
+
+	if err := os.WriteFile(path, []byte(lines[1]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileLog(path, testSecret); err == nil {
+		t.Error("expected an error for a log without its first entry")
+	}
+}
+
+func TestOpenFileLogUnterminatedEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	writeEntries(t, path, 2)
+	lines := readLines(t, path)
+
+	var entry Entry
+	json.Unmarshal([]byte(lines[1]), &entry)
+	entry.Hash = ComputeHash([]byte("other secret"), entry)
+	badMAC, _ := json.Marshal(entry)
+
+	tests := []struct {
+		name    string
+		tail    string
+		headSeq uint64
+	}{
+		// 줄바꿈만 쓰지 못한 entry 는 chain 이 맞으면 줄바꿈을 붙여서 유지합니다.
+		{"valid json with good mac", strings.TrimSuffix(lines[1], "\n"), 2},
+		{"valid json with bad mac", string(badMAC), 1},
+		{"invalid json", lines[1][:10], 1},
+	}
+	for _, test := range tests {
+		if err := os.WriteFile(path, []byte(lines[0]+test.tail), 0600); err != nil {
+			t.Fatal(err)
+		}
+		l, err := OpenFileLog(path, testSecret)
+		if err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+			continue
+		}
+		if seq, _ := l.Head(); seq != test.headSeq {
+			t.Errorf("%s: head seq = %d, want %d", test.name, seq, test.headSeq)
+		}
+		appendErr := l.Append(Entry{SessionId: "s", Outcome: SUCCEEDED})
+		l.file.Close()
+		if appendErr != nil {
+			t.Fatal(appendErr)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last, err := Verify(file, testSecret)
+		file.Close()
+		if err != nil || last.Seq != test.headSeq+1 {
+			t.Errorf("%s: Verify after recovery = %v, %v", test.name, last, err)
+		}
+	}
+}
+
+func TestParseSecret(t *testing.T) {
+	if _, err := ParseSecret(""); err == nil {
+		t.Error("expected an error for an empty secret")
+	}
+	if _, err := ParseSecret("not base64!"); err == nil {
+		t.Error("expected an error for a secret that is not base64")
+	}
+	if secret, err := ParseSecret("c2VjcmV0"); err != nil || string(secret) != "secret" {
+		t.Errorf("ParseSecret = %q, %v", secret, err)
+	}
+}
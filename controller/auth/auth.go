@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	NONE string = "none"
+	HMAC string = "hmac"
+	MTLS string = "mtls"
+)
+
+const (
+	UNAUTHORIZED      string = "UNAUTHORIZED"
+	REQUEST_TOO_LARGE string = "REQUEST_TOO_LARGE"
+)
+
+// 인증 전에 읽는 request body 의 최대 크기
+const MAX_BODY_SIZE int64 = 1 << 20
+
+// gin context key of the authenticated caller
+const identityKey = "auth.identity"
+
+var (
+	ErrUnauthorized = errors.New(UNAUTHORIZED)
+)
+
+// Authenticator verifies that a request comes from a trusted caller (the appserver)
+// and returns the identity of the caller.
+type Authenticator interface {
+	Authenticate(r *http.Request, body []byte) (identity string, err error)
+}
+
+type Config struct {
+	Mode             string
+	HMACKeyId        string
+	HMACSecret       string
+	MTLSAllowedNames []string
+}
+
+func NewAuthenticator(config Config) (Authenticator, error) {
+	switch config.Mode {
+	case "", HMAC:
+		return NewHMACAuthenticator(config.HMACKeyId, config.HMACSecret)
+	case MTLS:
+		return NewMTLSAuthenticator(config.MTLSAllowedNames), nil
+	case NONE:
+		log.Printf("[WARN] AUTH_MODE is none. requests to /v1 are not authenticated")
+		return noneAuthenticator{}, nil
+	}
+	return nil, fmt.Errorf("unsupported auth mode: %s", config.Mode)
+}
+
+// Middleware rejects requests the authenticator doesn't accept with 401.
+func Middleware(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MAX_BODY_SIZE))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": gin.H{
+					"text":    REQUEST_TOO_LARGE,
+					"message": err.Error(),
+				}})
+				return
+			}
+			abort(c, err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		identity, err := authenticator.Authenticate(c.Request, body)
+		if err != nil {
+			log.Printf("[auth] rejected. url: %s, error: %v", c.Request.URL, err)
+			abort(c, err)
+			return
+		}
+
+		c.Set(identityKey, identity)
+		c.Next()
+	}
+}
+
+// Identity returns the caller identity set by Middleware.
+func Identity(c *gin.Context) string {
+	return c.GetString(identityKey)
+}
+
+func abort(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": gin.H{
+		"text":    UNAUTHORIZED,
+		"message": err.Error(),
+	}})
+}
+
+type noneAuthenticator struct{}
+
+func (noneAuthenticator) Authenticate(r *http.Request, body []byte) (string, error) {
+	return "anonymous", nil
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HEADER_KEY_ID    string = "X-ABC-Key-Id"
+	HEADER_TIMESTAMP string = "X-ABC-Timestamp"
+	HEADER_NONCE     string = "X-ABC-Nonce"
+	HEADER_SIGNATURE string = "X-ABC-Signature"
+)
+
+// signed requests older than this are rejected
+const replayWindow = 5 * time.Minute
+
+// HMACAuthenticator accepts requests signed with a shared secret.
+//
+// signature = hex(HMAC-SHA256(secret, method \n requestURI \n timestamp \n nonce \n hex(sha256(body))))
+//
+// The timestamp must be within the replay window and a nonce can be used only once within the window.
+type HMACAuthenticator struct {
+	keyId  string
+	secret []byte
+	window time.Duration
+	nonces *nonceCache
+}
+
+// keyId is required because the key id of a request is recorded in the audit log as the caller.
+func NewHMACAuthenticator(keyId string, secret string) (*HMACAuthenticator, error) {
+	if keyId == "" {
+		return nil, fmt.Errorf("AUTH_HMAC_KEY_ID is required for hmac auth mode")
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("AUTH_HMAC_SECRET is required for hmac auth mode")
+	}
+	secretBytes, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("AUTH_HMAC_SECRET must be base64: %w", err)
+	}
+
+	return &HMACAuthenticator{
+		keyId:  keyId,
+		secret: secretBytes,
+		window: replayWindow,
+		nonces: newNonceCache(2 * replayWindow),
+	}, nil
+}
+
+func (a *HMACAuthenticator) Authenticate(r *http.Request, body []byte) (string, error) {
+	keyId := r.Header.Get(HEADER_KEY_ID)
+	timestamp := r.Header.Get(HEADER_TIMESTAMP)
+	nonce := r.Header.Get(HEADER_NONCE)
+	signature := r.Header.Get(HEADER_SIGNATURE)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return "", fmt.Errorf("%w: request is not signed", ErrUnauthorized)
+	}
+	if keyId != a.keyId {
+		return "", fmt.Errorf("%w: unknown key id: %s", ErrUnauthorized, keyId)
+	}
+
+	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid timestamp", ErrUnauthorized)
+	}
+	skew := time.Since(time.Unix(unixTime, 0))
+	if skew > a.window || skew < -a.window {
+		return "", fmt.Errorf("%w: timestamp is outside of the replay window", ErrUnauthorized)
+	}
+
+	expected := Sign(a.secret, r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return "", fmt.Errorf("%w: invalid signature", ErrUnauthorized)
+	}
+
+	// 서명이 맞는 요청만 nonce 를 기록해야 임의의 nonce 로 cache 를 채울 수 없습니다.
+	if !a.nonces.add(nonce) {
+		return "", fmt.Errorf("%w: replayed request", ErrUnauthorized)
+	}
+
+	return keyId, nil
+}
+
+// Sign returns the hex encoded signature of a request.
+func Sign(secret []byte, method string, requestURI string, timestamp string, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	message := strings.Join([]string{method, requestURI, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest sets the signature headers on a request the controller sends, such as a callback.
+func SignRequest(req *http.Request, keyId string, secret []byte, body []byte) error {
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set(HEADER_KEY_ID, keyId)
+	req.Header.Set(HEADER_TIMESTAMP, timestamp)
+	req.Header.Set(HEADER_NONCE, nonce)
+	req.Header.Set(HEADER_SIGNATURE, Sign(secret, req.Method, req.URL.RequestURI(), timestamp, nonce, body))
+	return nil
+}
+
+type nonceCache struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	nonces map[string]time.Time
+}
+
+func newNonceCache(ttl time.Duration) *nonceCache {
+	return &nonceCache{ttl: ttl, nonces: make(map[string]time.Time)}
+}
+
+// add returns false if the nonce has been seen within the ttl.
+func (n *nonceCache) add(nonce string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	for seen, expiresAt := range n.nonces {
+		if now.After(expiresAt) {
+			delete(n.nonces, seen)
+		}
+	}
+
+	if _, ok := n.nonces[nonce]; ok {
+		return false
+	}
+	n.nonces[nonce] = now.Add(n.ttl)
+	return true
+}
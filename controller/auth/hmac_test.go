@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestAuthenticator(t *testing.T) *HMACAuthenticator {
+	t.Helper()
+	authenticator, err := NewHMACAuthenticator("appserver", base64.StdEncoding.EncodeToString(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authenticator
+}
+
+func signedRequest(t *testing.T, keyId string, secret []byte, body []byte) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/v1/partialSign?x=1", bytes.NewReader(body))
+	if err := SignRequest(req, keyId, secret, body); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestNewHMACAuthenticatorRequiresKeyIdAndSecret(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(testSecret)
+	if _, err := NewHMACAuthenticator("", secret); err == nil {
+		t.Error("expected an error for an empty key id")
+	}
+	if _, err := NewHMACAuthenticator("appserver", ""); err == nil {
+		t.Error("expected an error for an empty secret")
+	}
+	if _, err := NewHMACAuthenticator("appserver", "not base64!"); err == nil {
+		t.Error("expected an error for a secret that is not base64")
+	}
+}
+
+func TestHMACAuthenticatorAcceptsSignedRequest(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	body := []byte(`{"keyId":"k"}`)
+
+	identity, err := authenticator.Authenticate(signedRequest(t, "appserver", testSecret, body), body)
+	if err != nil {
+		t.Fatalf("Authenticate error: %v", err)
+	}
+	if identity != "appserver" {
+		t.Errorf("identity = %s, want appserver", identity)
+	}
+}
+
+func TestHMACAuthenticatorRejectsInvalidRequests(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	body := []byte(`{"keyId":"k"}`)
+
+	tests := map[string]func() (*http.Request, []byte){
+		"unsigned": func() (*http.Request, []byte) {
+			return httptest.NewRequest("POST", "/v1/partialSign", bytes.NewReader(body)), body
+		},
+		"unknown key id": func() (*http.Request, []byte) {
+			return signedRequest(t, "other", testSecret, body), body
+		},
+		"wrong secret": func() (*http.Request, []byte) {
+			return signedRequest(t, "appserver", []byte("another secret"), body), body
+		},
+		"changed body": func() (*http.Request, []byte) {
+			return signedRequest(t, "appserver", testSecret, body), []byte(`{"keyId":"other"}`)
+		},
+		"changed uri": func() (*http.Request, []byte) {
+			req := signedRequest(t, "appserver", testSecret, body)
+			req.URL.RawQuery = "x=2"
+			return req, body
+		},
+		"expired timestamp": func() (*http.Request, []byte) {
+			req := httptest.NewRequest("POST", "/v1/partialSign", bytes.NewReader(body))
+			timestamp := strconv.FormatInt(time.Now().Add(-2*replayWindow).Unix(), 10)
+			req.Header.Set(HEADER_KEY_ID, "appserver")
+			req.Header.Set(HEADER_TIMESTAMP, timestamp)
+			req.Header.Set(HEADER_NONCE, "nonce")
+			req.Header.Set(HEADER_SIGNATURE, Sign(testSecret, "POST", "/v1/partialSign", timestamp, "nonce", body))
+			return req, body
+		},
+	}
+	for name, request := range tests {
+		req, body := request()
+		if _, err := authenticator.Authenticate(req, body); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("%s: error = %v, want %v", name, err, ErrUnauthorized)
+		}
+	}
+}
+
+func TestHMACAuthenticatorRejectsReplayedRequest(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	body := []byte(`{}`)
+	req := signedRequest(t, "appserver", testSecret, body)
+
+	if _, err := authenticator.Authenticate(req, body); err != nil {
+		t.Fatalf("first request error: %v", err)
+	}
+	if _, err := authenticator.Authenticate(req, body); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("replayed request error = %v, want %v", err, ErrUnauthorized)
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	nonces := newNonceCache(time.Hour)
+	if !nonces.add("a") {
+		t.Error("first nonce was rejected")
+	}
+	if nonces.add("a") {
+		t.Error("repeated nonce was accepted")
+	}
+	if !nonces.add("b") {
+		t.Error("another nonce was rejected")
+	}
+
+	expiring := newNonceCache(-time.Second)
+	expiring.add("a")
+	if !expiring.add("a") {
+		t.Error("expired nonce was rejected")
+	}
+	if len(expiring.nonces) != 1 {
+		t.Errorf("expired nonces are kept: %d", len(expiring.nonces))
+	}
+}
+
+func TestMiddlewareRejectsLargeBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/v1/partialSign", Middleware(newTestAuthenticator(t)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	body := bytes.Repeat([]byte("a"), int(MAX_BODY_SIZE)+1)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, signedRequest(t, "appserver", testSecret, body))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// MTLSAuthenticator accepts requests whose client certificate was verified by the TLS server.
+// If allowedNames is not empty, the common name of the certificate must be one of them.
+type MTLSAuthenticator struct {
+	allowedNames []string
+}
+
+func NewMTLSAuthenticator(allowedNames []string) *MTLSAuthenticator {
+	return &MTLSAuthenticator{allowedNames: allowedNames}
+}
+
+func (a *MTLSAuthenticator) Authenticate(r *http.Request, body []byte) (string, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", fmt.Errorf("%w: client certificate is required", ErrUnauthorized)
+	}
+
+	commonName := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	if len(a.allowedNames) > 0 && !slices.Contains(a.allowedNames, commonName) {
+		return "", fmt.Errorf("%w: client certificate is not allowed: %s", ErrUnauthorized, commonName)
+	}
+	return commonName, nil
+}
+
+// ServerTLSConfig returns the TLS config of the server. client certificates signed by
+// clientCAFile are required when clientCAFile is set.
+func ServerTLSConfig(clientCAFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if clientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	caPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate found in %s", clientCAFile)
+	}
+
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
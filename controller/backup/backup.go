@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ALGORITHM 은 share backup 을 암호화하는 방식입니다.
+// 임의의 AES-256 key 로 share 를 AES-GCM 암호화하고, 그 key 를 operator 의 RSA public key 로 RSA-OAEP(SHA-256) 암호화합니다.
+const ALGORITHM string = "RSA-OAEP-256+A256GCM"
+
+const MIN_RSA_KEY_BITS int = 2048
+
+var (
+	ErrInvalidRecipientKey = errors.New("invalid recipient public key")
+	ErrRecipientNotAllowed = errors.New("recipient public key is not allowed")
+	ErrDecryption          = errors.New("failed to decrypt share backup")
+)
+
+// Envelope is an encrypted share backup of one node.
+// KeyId and PlayerIndex are bound to the ciphertext as additional data, so they can't be swapped between backups.
+type Envelope struct {
+	Algorithm      string    `json:"algorithm" example:"RSA-OAEP-256+A256GCM"`
+	KeyId          string    `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	PlayerIndex    string    `json:"playerIndex" example:"1"`
+	RecipientKeyId string    `json:"recipientKeyId" example:"9f2c6a0de1b34c5a8f7e2d1c0b9a8f7e6d5c4b3a291817161514131211100f0e"` // sha256 of the recipient SubjectPublicKeyInfo. hex
+	EncryptedKey   string    `json:"encryptedKey" example:"base64"`                                                             // RSA-OAEP encrypted AES key. base64
+	Nonce          string    `json:"nonce" example:"base64"`                                                                    // base64
+	Ciphertext     string    `json:"ciphertext" example:"base64"`                                                               // AES-GCM encrypted share backup. base64
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Recipient is an operator RSA public key that backups and ERS recovery data are encrypted to.
+type Recipient struct {
+	PublicKey *rsa.PublicKey
+	KeyId     string
+}
+
+// ParseRecipient parses a base64 encoded SubjectPublicKeyInfo of an RSA key of at least 2048 bits.
+func ParseRecipient(publicKey string) (*Recipient, error) {
+	der, err := base64.StdEncoding.Strict().DecodeString(strings.TrimSpace(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRecipientKey, err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRecipientKey, err)
+	}
+	rsaKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an RSA key", ErrInvalidRecipientKey)
+	}
+	if rsaKey.N.BitLen() < MIN_RSA_KEY_BITS {
+		return nil, fmt.Errorf("%w: RSA key must be at least %d bits", ErrInvalidRecipientKey, MIN_RSA_KEY_BITS)
+	}
+	return &Recipient{PublicKey: rsaKey, KeyId: Fingerprint(der)}, nil
+}
+
+// Fingerprint returns the hex sha256 of a DER SubjectPublicKeyInfo.
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// Allowed reports whether the recipient is one of the fingerprints configured by the operator.
+func (r *Recipient) Allowed(fingerprints []string) bool {
+	for _, fingerprint := range fingerprints {
+		if strings.EqualFold(fingerprint, r.KeyId) {
+			return true
+		}
+	}
+	return false
+}
+
+// Seal encrypts a share backup of keyId taken on playerIndex to the recipient.
+func Seal(recipient *Recipient, keyId string, playerIndex string, shareBackup []byte) (*Envelope, error) {
+	contentKey := make([]byte, 32)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+	defer clear(contentKey)
+
+	gcm, err := newGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nil, nonce, shareBackup, additionalData(keyId, playerIndex))
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, recipient.PublicKey, contentKey, []byte(ALGORITHM))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		Algorithm:      ALGORITHM,
+		KeyId:          keyId,
+		PlayerIndex:    playerIndex,
+		RecipientKeyId: recipient.KeyId,
+		EncryptedKey:   base64.StdEncoding.EncodeToString(encryptedKey),
+		Nonce:          base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext:     base64.StdEncoding.EncodeToString(ciphertext),
+		CreatedAt:      time.Now().UTC(),
+	}, nil
+}
+
+// Open decrypts an envelope with the operator's private key. The result can be restored with RestoreKeyShare.
+func Open(privateKey *rsa.PrivateKey, envelope *Envelope) ([]byte, error) {
+	if envelope.Algorithm != ALGORITHM {
+		return nil, fmt.Errorf("unsupported backup algorithm: %s", envelope.Algorithm)
+	}
+	encryptedKey, err := base64.StdEncoding.DecodeString(envelope.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryption, err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryption, err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryption, err)
+	}
+
+	contentKey, err := rsa.DecryptOAEP(sha256.New(), nil, privateKey, encryptedKey, []byte(ALGORITHM))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryption, err)
+	}
+	defer clear(contentKey)
+
+	gcm, err := newGCM(contentKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryption, err)
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrDecryption)
+	}
+	shareBackup, err := gcm.Open(nil, nonce, ciphertext, additionalData(envelope.KeyId, envelope.PlayerIndex))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryption, err)
+	}
+	return shareBackup, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func additionalData(keyId string, playerIndex string) []byte {
+	return []byte(ALGORITHM + "|" + keyId + "|" + playerIndex)
+}
@@ -0,0 +1,122 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newRecipientKey(t *testing.T, bits int) (*rsa.PrivateKey, string) {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, base64.StdEncoding.EncodeToString(der)
+}
+
+func TestParseRecipient(t *testing.T) {
+	_, smallKey := newRecipientKey(t, 1024)
+	tests := map[string]string{
+		"not base64":     "not base64!",
+		"not a key":      base64.StdEncoding.EncodeToString([]byte("not a key")),
+		"RSA 1024 bits":  smallKey,
+		"empty key text": "",
+	}
+	for name, publicKey := range tests {
+		if _, err := ParseRecipient(publicKey); !errors.Is(err, ErrInvalidRecipientKey) {
+			t.Errorf("%s: error = %v, want %v", name, err, ErrInvalidRecipientKey)
+		}
+	}
+}
+
+func TestRecipientAllowed(t *testing.T) {
+	_, publicKey := newRecipientKey(t, MIN_RSA_KEY_BITS)
+	recipient, err := ParseRecipient(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := base64.StdEncoding.DecodeString(publicKey)
+	if recipient.KeyId != Fingerprint(der) {
+		t.Errorf("KeyId = %s, want the fingerprint of the key", recipient.KeyId)
+	}
+
+	tests := []struct {
+		name         string
+		fingerprints []string
+		allowed      bool
+	}{
+		{"listed", []string{"other", recipient.KeyId}, true},
+		{"upper case", []string{strings.ToUpper(recipient.KeyId)}, true},
+		{"not listed", []string{"other"}, false},
+		{"empty", nil, false},
+	}
+	for _, test := range tests {
+		if got := recipient.Allowed(test.fingerprints); got != test.allowed {
+			t.Errorf("%s: Allowed = %v, want %v", test.name, got, test.allowed)
+		}
+	}
+}
+
+func TestSealAndOpen(t *testing.T) {
+	privateKey, publicKey := newRecipientKey(t, MIN_RSA_KEY_BITS)
+	recipient, err := ParseRecipient(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := newRecipientKey(t, MIN_RSA_KEY_BITS)
+
+	shareBackup := []byte("share backup")
+	envelope, err := Seal(recipient, "k1", "1", shareBackup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Algorithm != ALGORITHM || envelope.RecipientKeyId != recipient.KeyId {
+		t.Errorf("envelope = %+v", envelope)
+	}
+
+	opened, err := Open(privateKey, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, shareBackup) {
+		t.Errorf("Open = %q, want %q", opened, shareBackup)
+	}
+
+	ciphertext, _ := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	ciphertext[0] ^= 0xff
+
+	// keyId, playerIndex 는 additional data 이므로 바꾸면 복호화에 실패합니다.
+	tests := map[string]func(e *Envelope){
+		"other keyId":        func(e *Envelope) { e.KeyId = "k2" },
+		"other playerIndex":  func(e *Envelope) { e.PlayerIndex = "2" },
+		"changed ciphertext": func(e *Envelope) { e.Ciphertext = base64.StdEncoding.EncodeToString(ciphertext) },
+		"invalid nonce":      func(e *Envelope) { e.Nonce = base64.StdEncoding.EncodeToString([]byte("short")) },
+		"not base64":         func(e *Envelope) { e.EncryptedKey = "not base64!" },
+	}
+	for name, change := range tests {
+		tampered := *envelope
+		change(&tampered)
+		if _, err := Open(privateKey, &tampered); !errors.Is(err, ErrDecryption) {
+			t.Errorf("%s: error = %v, want %v", name, err, ErrDecryption)
+		}
+	}
+
+	if _, err := Open(otherKey, envelope); !errors.Is(err, ErrDecryption) {
+		t.Errorf("other private key: error = %v, want %v", err, ErrDecryption)
+	}
+	unsupported := *envelope
+	unsupported.Algorithm = "RSA-OAEP+A128GCM"
+	if _, err := Open(privateKey, &unsupported); err == nil {
+		t.Error("expected an error for an unsupported algorithm")
+	}
+}
@@ -0,0 +1,115 @@
+package callback
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ahnlabio/tsm-controller/auth"
+)
+
+// 전송 실패 시 재시도 횟수. 재시도 간격은 1초부터 두 배씩 늘어납니다.
+const maxAttempts = 3
+
+// Event is posted to CALLBACK_URL when a keygen, copy, reshare, import or presign session finishes on this node.
+type Event struct {
+	SessionId       string    `json:"sessionId" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	PlayerIndex     string    `json:"playerIndex" example:"1"`
+	Operation       string    `json:"operation" example:"generateKey"`
+	Status          string    `json:"status" example:"succeeded"`
+	KeyId           string    `json:"keyId,omitempty" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	PresignatureIds []string  `json:"presignatureIds,omitempty"`
+	ErrorText       string    `json:"errorText,omitempty" example:"SESSION_TIMEOUT"`
+	Error           string    `json:"error,omitempty"`
+	FinishedAt      time.Time `json:"finishedAt"`
+}
+
+// Notifier posts HMAC signed events to the appserver.
+// The signature uses the same headers and format as requests from the appserver to the controller.
+type Notifier struct {
+	url        string
+	keyId      string
+	secret     []byte
+	httpClient *http.Client
+}
+
+// NewNotifier returns a notifier that does nothing if url is empty.
+func NewNotifier(url string, keyId string, secret string) (*Notifier, error) {
+	if url == "" {
+		return &Notifier{}, nil
+	}
+	// appserver 는 key id 로 어느 player 의 callback 인지 확인합니다.
+	if keyId == "" {
+		return nil, fmt.Errorf("CALLBACK_HMAC_KEY_ID is required when CALLBACK_URL is set")
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("CALLBACK_HMAC_SECRET is required when CALLBACK_URL is set")
+	}
+	secretBytes, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("CALLBACK_HMAC_SECRET must be base64: %w", err)
+	}
+
+	return &Notifier{
+		url:        url,
+		keyId:      keyId,
+		secret:     secretBytes,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Notify sends the event, retrying on failure. It blocks, so call it in a goroutine.
+func (n *Notifier) Notify(event Event) {
+	if n.url == "" {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[callback] failed to json.Marshal. error: %v", err)
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = n.post(body)
+		if err == nil {
+			log.Printf("[callback] sent. sessionId: %s, status: %s", event.SessionId, event.Status)
+			return
+		}
+		log.Printf("[callback] failed. sessionId: %s, attempt: %d, error: %v", event.SessionId, attempt, err)
+		if attempt < maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+func (n *Notifier) post(body []byte) error {
+	req, err := http.NewRequest("POST", n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "ABC")
+	req.Header.Set("Content-Type", "application/json")
+	if err := auth.SignRequest(req, n.keyId, n.secret, body); err != nil {
+		return err
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ahnlabio/tsm-controller/audit"
+)
+
+// auditverify checks that an audit log exported from the controller has not been tampered with.
+// The chain is keyed, so AUDIT_HMAC_SECRET of the node must be set.
+// Removed trailing entries can only be detected by passing the last head hash logged by the controller.
+//
+//	AUDIT_HMAC_SECRET=... go run ./cmd/auditverify audit.jsonl [head hash]
+func main() {
+	if len(os.Args) != 2 && len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: auditverify <audit log file> [head hash]")
+		os.Exit(2)
+	}
+
+	secret, err := audit.ParseSecret(os.Getenv("AUDIT_HMAC_SECRET"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
+	file, err := os.Open(os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open audit log: %v\n", err)
+		os.Exit(2)
+	}
+	defer file.Close()
+
+	last, err := audit.Verify(file, secret)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "TAMPERED: %v\n", err)
+		os.Exit(1)
+	}
+
+	count := uint64(0)
+	headHash := audit.GENESIS_HASH
+	if last != nil {
+		count = last.Seq
+		headHash = last.Hash
+	}
+	if len(os.Args) == 3 && os.Args[2] != headHash {
+		fmt.Fprintf(os.Stderr, "TAMPERED: last entry (seq %d) does not match the head hash\n", count)
+		os.Exit(1)
+	}
+	fmt.Printf("OK: %d entries verified. head: %s\n", count, headHash)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ahnlabio/tsm-controller/backup"
+)
+
+// backupdecrypt decrypts a share backup exported from the controller with the operator's RSA private key (PEM, PKCS#8 or PKCS#1).
+// The decrypted share is written to the output file and can be restored on the node with RestoreKeyShare.
+//
+//	go run ./cmd/backupdecrypt operator.pem backup.json share.bin
+func main() {
+	if len(os.Args) != 4 {
+		fmt.Fprintln(os.Stderr, "usage: backupdecrypt <private key pem> <backup json> <output file>")
+		os.Exit(2)
+	}
+
+	privateKey, err := readPrivateKey(os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read private key: %v\n", err)
+		os.Exit(2)
+	}
+
+	envelopeBytes, err := os.ReadFile(os.Args[2])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read backup: %v\n", err)
+		os.Exit(2)
+	}
+	var envelope backup.Envelope
+	if err := json.Unmarshal(envelopeBytes, &envelope); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse backup: %v\n", err)
+		os.Exit(2)
+	}
+
+	shareBackup, err := backup.Open(privateKey, &envelope)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(os.Args[3], shareBackup, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write share: %v\n", err)
+		os.Exit(2)
+	}
+	fmt.Printf("OK: keyId: %s, playerIndex: %s, createdAt: %s\n", envelope.KeyId, envelope.PlayerIndex, envelope.CreatedAt)
+}
+
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return rsaKey, nil
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
 )
 
 type Config struct {
@@ -11,8 +14,31 @@ type Config struct {
 	BuildType            string `env:"BUILD_TYPE"`
 	NodeUrl              string `env:"NODE_URL"`
 	NodeApiKey           string `env:"NODE_API_KEY"`
+	NodeHealthCheck      string `env:"NODE_HEALTH_CHECK_INTERVAL"`
 	NodePubicKey         string `env:"NODE_PUBLIC_KEY"`
 	AnotherNodePublicKey string `env:"ANOTHER_NODE_PUBLIC_KEY"`
+	KeyPolicy            string `env:"KEY_POLICY"`
+	KeygenTimeout        string `env:"KEYGEN_TIMEOUT"`
+	CopyKeyTimeout       string `env:"COPY_KEY_TIMEOUT"`
+	PresignTimeout       string `env:"PRESIGN_TIMEOUT"`
+	ReshareTimeout       string `env:"RESHARE_TIMEOUT"`
+	ERSTimeout           string `env:"ERS_TIMEOUT"`
+	AuthMode             string `env:"AUTH_MODE"`
+	AuthHMACKeyId        string `env:"AUTH_HMAC_KEY_ID"`
+	AuthHMACSecret       string `env:"AUTH_HMAC_SECRET"`
+	AuthMTLSAllowedNames string `env:"AUTH_MTLS_ALLOWED_NAMES"`
+	TLSCertFile          string `env:"TLS_CERT_FILE"`
+	TLSKeyFile           string `env:"TLS_KEY_FILE"`
+	TLSClientCAFile      string `env:"TLS_CLIENT_CA_FILE"`
+	CORSAllowedOrigins   string `env:"CORS_ALLOWED_ORIGINS"`
+	CallbackUrl          string `env:"CALLBACK_URL"`
+	CallbackHMACKeyId    string `env:"CALLBACK_HMAC_KEY_ID"`
+	CallbackHMACSecret   string `env:"CALLBACK_HMAC_SECRET"`
+	AuditLogFile         string `env:"AUDIT_LOG_FILE"`
+	AuditHMACSecret      string `env:"AUDIT_HMAC_SECRET"`
+	PolicyFile           string `env:"POLICY_FILE"`
+	BackupRecipients     string `env:"BACKUP_RECIPIENT_FINGERPRINTS"`
+	ERSRecipients        string `env:"ERS_RECIPIENT_FINGERPRINTS"`
 }
 
 func GetConfig() *Config {
@@ -23,7 +49,85 @@ func GetConfig() *Config {
 		PlayerIndex:          os.Getenv("PLAYER_INDEX"),
 		NodeUrl:              os.Getenv("NODE_URL"),
 		NodeApiKey:           os.Getenv("NODE_API_KEY"),
+		NodeHealthCheck:      os.Getenv("NODE_HEALTH_CHECK_INTERVAL"),
 		NodePubicKey:         os.Getenv("NODE_PUBLIC_KEY"),
 		AnotherNodePublicKey: os.Getenv("ANOTHER_NODE_PUBLIC_KEY"),
+		KeyPolicy:            os.Getenv("KEY_POLICY"),
+		KeygenTimeout:        os.Getenv("KEYGEN_TIMEOUT"),
+		CopyKeyTimeout:       os.Getenv("COPY_KEY_TIMEOUT"),
+		PresignTimeout:       os.Getenv("PRESIGN_TIMEOUT"),
+		ReshareTimeout:       os.Getenv("RESHARE_TIMEOUT"),
+		ERSTimeout:           os.Getenv("ERS_TIMEOUT"),
+		AuthMode:             os.Getenv("AUTH_MODE"),
+		AuthHMACKeyId:        os.Getenv("AUTH_HMAC_KEY_ID"),
+		AuthHMACSecret:       os.Getenv("AUTH_HMAC_SECRET"),
+		AuthMTLSAllowedNames: os.Getenv("AUTH_MTLS_ALLOWED_NAMES"),
+		TLSCertFile:          os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:           os.Getenv("TLS_KEY_FILE"),
+		TLSClientCAFile:      os.Getenv("TLS_CLIENT_CA_FILE"),
+		CORSAllowedOrigins:   os.Getenv("CORS_ALLOWED_ORIGINS"),
+		CallbackUrl:          os.Getenv("CALLBACK_URL"),
+		CallbackHMACKeyId:    os.Getenv("CALLBACK_HMAC_KEY_ID"),
+		CallbackHMACSecret:   os.Getenv("CALLBACK_HMAC_SECRET"),
+		AuditLogFile:         os.Getenv("AUDIT_LOG_FILE"),
+		AuditHMACSecret:      os.Getenv("AUDIT_HMAC_SECRET"),
+		PolicyFile:           os.Getenv("POLICY_FILE"),
+		BackupRecipients:     os.Getenv("BACKUP_RECIPIENT_FINGERPRINTS"),
+		ERSRecipients:        os.Getenv("ERS_RECIPIENT_FINGERPRINTS"),
+	}
+}
+
+// SplitList splits a comma separated env value.
+func SplitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// BackupRecipientFingerprints returns the sha256 fingerprints of the RSA keys share backups may be encrypted to.
+// fingerprint 는 DER SubjectPublicKeyInfo 의 sha256 (hex) 입니다. e.g. openssl pkey -pubin -in operator.pem -outform DER | sha256sum
+func (c *Config) BackupRecipientFingerprints() []string {
+	return SplitList(c.BackupRecipients)
+}
+
+// ERSRecipientFingerprints returns the sha256 fingerprints of the ERS RSA keys recovery data may be encrypted to.
+func (c *Config) ERSRecipientFingerprints() []string {
+	return SplitList(c.ERSRecipients)
+}
+
+// processNodeSettings 는 godotenv.Load 전에 process 환경 변수로 지정된 node 설정입니다.
+// package 초기화는 main 보다 먼저 실행되므로 .env 에서 읽은 값이 섞이지 않습니다.
+var processNodeSettings = lookupEnv("NODE_URL", "NODE_API_KEY")
+
+func lookupEnv(keys ...string) map[string]string {
+	values := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(key); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// ReadNodeSettings returns NODE_URL and NODE_API_KEY.
+// .env 파일을 매번 다시 읽으므로 재시작 없이 node 주소나 API key 변경이 반영됩니다.
+// godotenv.Load 와 같이 process 환경 변수가 .env 보다 우선합니다.
+func ReadNodeSettings() (nodeUrl string, nodeApiKey string) {
+	return readNodeSetting("NODE_URL"), readNodeSetting("NODE_API_KEY")
+}
+
+func readNodeSetting(key string) string {
+	if value, ok := processNodeSettings[key]; ok {
+		return value
+	}
+	if values, err := godotenv.Read(); err == nil {
+		if value, ok := values[key]; ok {
+			return value
+		}
 	}
+	return os.Getenv(key)
 }
@@ -1,6 +1,9 @@
 package container
 
 import (
+	"log"
+
+	"github.com/ahnlabio/tsm-controller/auth"
 	"github.com/ahnlabio/tsm-controller/config"
 	"github.com/ahnlabio/tsm-controller/handlers"
 	"github.com/ahnlabio/tsm-controller/service"
@@ -9,9 +12,10 @@ import (
 var container *Container
 
 type Container struct {
-	AppConfig  *config.Config
-	TsmService *service.TSMService
-	Handlers   *handlers.Handlers
+	AppConfig     *config.Config
+	TsmService    *service.TSMService
+	Handlers      *handlers.Handlers
+	Authenticator auth.Authenticator
 }
 
 func GetInstnace() *Container {
@@ -19,11 +23,24 @@ func GetInstnace() *Container {
 		appConfig := config.GetConfig()
 		tsmService := service.NewTSMService(appConfig)
 		handers := handlers.NewHandler(tsmService)
+		if appConfig.AuthMode == auth.MTLS && (appConfig.TLSCertFile == "" || appConfig.TLSClientCAFile == "") {
+			log.Fatalf("AUTH_MODE=mtls requires TLS_CERT_FILE, TLS_KEY_FILE and TLS_CLIENT_CA_FILE")
+		}
+		authenticator, err := auth.NewAuthenticator(auth.Config{
+			Mode:             appConfig.AuthMode,
+			HMACKeyId:        appConfig.AuthHMACKeyId,
+			HMACSecret:       appConfig.AuthHMACSecret,
+			MTLSAllowedNames: config.SplitList(appConfig.AuthMTLSAllowedNames),
+		})
+		if err != nil {
+			log.Fatalf("failed to create authenticator: %v", err)
+		}
 
 		container = &Container{
-			AppConfig:  appConfig,
-			TsmService: tsmService,
-			Handlers:   handers,
+			AppConfig:     appConfig,
+			TsmService:    tsmService,
+			Handlers:      handers,
+			Authenticator: authenticator,
 		}
 	}
 	return container
@@ -32,3 +49,7 @@ func GetInstnace() *Container {
 func (c *Container) GetHandlers() *handlers.Handlers {
 	return c.Handlers
 }
+
+func (c *Container) GetAuthenticator() auth.Authenticator {
+	return c.Authenticator
+}
@@ -38,6 +38,55 @@ const docTemplate = `{
                 }
             }
         },
+        "/ready": {
+            "get": {
+                "description": "Report whether the MPC node passed its last health check. Returns 503 until it does.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "info"
+                ],
+                "summary": "Check readiness",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReadyResponseBody"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReadyResponseBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/audit/export": {
+            "get": {
+                "description": "Download the hash-chained audit log of key operations as JSON lines. Verify it with cmd/auditverify.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Export the audit log",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/audit.Entry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/v1/copyKey": {
             "post": {
                 "description": "Copy a session key",
@@ -100,9 +149,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/presign": {
+        "/v1/importKey": {
             "post": {
-                "description": "Start a presign session",
+                "description": "Import an existing private key secret-shared by the mobile player. The session fails unless the shares form the key of pkixPublicKey, so the imported key has the public key of the existing wallet.",
                 "consumes": [
                     "application/json"
                 ],
@@ -112,17 +161,85 @@ const docTemplate = `{
                 "tags": [
                     "session"
                 ],
-                "summary": "Start a presign session",
+                "summary": "Start an import key session",
                 "parameters": [
                     {
-                        "description": "Public key",
+                        "description": "Wrapped key share and public key",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.PresignRequestBody"
+                            "$ref": "#/definitions/handlers.ImportKeyRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/v1/keys": {
+            "get": {
+                "description": "List the keys this node holds a share of, sorted by key ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "List keys",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "limit. max 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.KeyList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
                         }
                     }
+                }
+            }
+        },
+        "/v1/keys/{keyId}": {
+            "delete": {
+                "description": "Delete this node's share of a key and its presignatures",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Delete a key share",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
@@ -131,9 +248,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/sign": {
+        "/v1/keys/{keyId}/backup": {
             "post": {
-                "description": "Finalize a sign session",
+                "description": "Export this node's share of a key encrypted with RSA-OAEP-256 and AES-256-GCM to an operator RSA public key. The key must be listed in BACKUP_RECIPIENT_FINGERPRINTS. Decrypt it with cmd/backupdecrypt.",
                 "consumes": [
                     "application/json"
                 ],
@@ -141,131 +258,1005 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "session"
+                    "key"
                 ],
-                "summary": "Finalize a sign session",
+                "summary": "Export an encrypted backup of this node's key share",
                 "parameters": [
                     {
-                        "description": "Public key",
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Operator public key",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SignRequestBody"
+                            "$ref": "#/definitions/handlers.BackupKeyShareRequestBody"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backup.Envelope"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.CopyKeyRequestBody": {
-            "type": "object",
-            "required": [
-                "existingKeyId",
-                "publicKey",
-                "sessionId"
-            ],
-            "properties": {
-                "existingKeyId": {
-                    "type": "string",
-                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
-                },
-                "publicKey": {
-                    "type": "string",
-                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
-                },
-                "sessionId": {
-                    "type": "string",
-                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
-                }
-            }
         },
-        "handlers.GenerateKeyRequestBody": {
-            "type": "object",
-            "required": [
-                "publicKey",
-                "sessionId"
-            ],
-            "properties": {
-                "publicKey": {
-                    "type": "string",
-                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
-                },
-                "sessionId": {
-                    "type": "string",
-                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+        "/v1/keys/{keyId}/presignatures": {
+            "get": {
+                "description": "Get the presignature IDs generated on this node for a key and how many of them are consumed. Only the 100 most recently consumed IDs are listed. presign runs on player 1 only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Get presignatures of a key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/presignature.Summary"
+                        }
+                    }
                 }
             }
         },
-        "handlers.PresignRequestBody": {
-            "type": "object",
-            "required": [
-                "count",
-                "keyId",
-                "publicKey",
-                "sessionId"
-            ],
-            "properties": {
-                "count": {
-                    "type": "integer",
-                    "example": 3
-                },
-                "keyId": {
-                    "type": "string",
-                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
-                },
-                "publicKey": {
-                    "type": "string",
-                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
-                },
-                "sessionId": {
-                    "type": "string",
-                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+        "/v1/keys/{keyId}/publicKey": {
+            "get": {
+                "description": "Get the public key of a key, optionally derived with a non-hardened BIP32 path. hex, base64 and base58 are encodings of the raw public key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Get the public key of a key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "schnorr (default) or ecdsa",
+                        "name": "algorithm",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "non-hardened derivation path. e.g. m/44/501/0",
+                        "name": "derivationPath",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PublicKeyResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    }
                 }
             }
         },
-        "handlers.SignRequestBody": {
-            "type": "object",
-            "required": [
-                "keyId",
-                "messageHash",
-                "signSignatureId"
-            ],
-            "properties": {
-                "keyId": {
-                    "type": "string",
-                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
-                },
-                "messageHash": {
-                    "type": "string",
-                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
-                },
-                "signSignatureId": {
-                    "type": "string",
-                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+        "/v1/keys/{keyId}/recoveryData": {
+            "post": {
+                "description": "Run an ERS session with the other server player and return this player's partial recovery data, encrypted to the ERS public key. The ERS key must be listed in ERS_RECIPIENT_FINGERPRINTS. The appserver must call both players with the same session ID, ERS key and label. Fails with SESSION_TIMEOUT if the other player doesn't join within ERS_TIMEOUT.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Generate partial ERS recovery data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Session ID and ERS public key",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecoveryDataRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecoveryDataResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    }
                 }
             }
         },
-        "main.RootResponse": {
-            "type": "object",
-            "properties": {
-                "build_type": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "time": {
-                    "type": "string"
-                },
-                "version": {
-                    "type": "string"
+        "/v1/partialSign": {
+            "post": {
+                "description": "Finalize a sign session",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Finalize a sign session",
+                "parameters": [
+                    {
+                        "description": "Public key",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SignRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/v1/partialSignBatch": {
+            "post": {
+                "description": "Partial sign up to 100 (presignatureId, messageHash, keyId) items in one request. Items are signed in order and independently: a failed item does not stop or roll back the others and its error is returned in place of the signature with the same text as /v1/partialSign. The request fails as a whole (400) only if the body or an item is malformed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Partial sign a batch of messages",
+                "parameters": [
+                    {
+                        "description": "Sign items",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SignBatchRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SignBatchResponseBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/preSign": {
+            "post": {
+                "description": "Start a presign session",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Start a presign session",
+                "parameters": [
+                    {
+                        "description": "Public key",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PresignRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/v1/reshareKey": {
+            "post": {
+                "description": "Refresh the secret sharing of a key with all players. The public key and key ID are kept; old shares, backups of them and presignatures of the key become invalid.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Start a reshare session",
+                "parameters": [
+                    {
+                        "description": "Public key and key ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReshareKeyRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{sessionId}": {
+            "get": {
+                "description": "Get the status and result of a keygen, copy, reshare, import or presign session started on this node",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Get a session status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/session.Session"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions/{sessionId}/cancel": {
+            "post": {
+                "description": "Cancel a pending or running keygen, copy, reshare, import or presign session so the node stops waiting for the mobile player",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Cancel a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/session.Session"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommonErrorObject"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/wrappingKey": {
+            "get": {
+                "description": "Get the RSA key the mobile player must wrap this node's key share with before an import",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "key"
+                ],
+                "summary": "Get the wrapping key of this node",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WrappingKeyResponseBody"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "audit.Entry": {
+            "type": "object",
+            "properties": {
+                "caller": {
+                    "type": "string",
+                    "example": "appserver"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "messageHashDigest": {
+                    "description": "hex(sha256(message hash)) of a partial signature",
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "partialSign"
+                },
+                "outcome": {
+                    "type": "string",
+                    "example": "succeeded"
+                },
+                "prevHash": {
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "backup.Envelope": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "RSA-OAEP-256+A256GCM"
+                },
+                "ciphertext": {
+                    "description": "AES-GCM encrypted share backup. base64",
+                    "type": "string",
+                    "example": "base64"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "encryptedKey": {
+                    "description": "RSA-OAEP encrypted AES key. base64",
+                    "type": "string",
+                    "example": "base64"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "nonce": {
+                    "description": "base64",
+                    "type": "string",
+                    "example": "base64"
+                },
+                "playerIndex": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "recipientKeyId": {
+                    "description": "sha256 of the recipient SubjectPublicKeyInfo. hex",
+                    "type": "string",
+                    "example": "9f2c6a0de1b34c5a8f7e2d1c0b9a8f7e6d5c4b3a291817161514131211100f0e"
+                }
+            }
+        },
+        "handlers.BackupKeyShareRequestBody": {
+            "type": "object",
+            "required": [
+                "publicKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "publicKey": {
+                    "description": "base64 SubjectPublicKeyInfo of an operator RSA key (2048 bits or more)",
+                    "type": "string",
+                    "example": "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."
+                }
+            }
+        },
+        "handlers.CommonErrorObject": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": ""
+                },
+                "text": {
+                    "type": "string",
+                    "example": ""
+                }
+            }
+        },
+        "handlers.CopyKeyRequestBody": {
+            "type": "object",
+            "required": [
+                "existingKeyId",
+                "publicKey",
+                "sessionId"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "curve": {
+                    "description": "default curve of the algorithm if empty",
+                    "type": "string",
+                    "example": "ED-25519"
+                },
+                "existingKeyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                },
+                "threshold": {
+                    "description": "1 if empty",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.GenerateKeyRequestBody": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "sessionId"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "curve": {
+                    "description": "default curve of the algorithm if empty",
+                    "type": "string",
+                    "example": "ED-25519"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                },
+                "threshold": {
+                    "description": "1 if empty",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.ImportKeyRequestBody": {
+            "type": "object",
+            "required": [
+                "pkixPublicKey",
+                "publicKey",
+                "sessionId",
+                "wrappedKeyShare"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "pkixPublicKey": {
+                    "description": "public key of the imported key. base64 SubjectPublicKeyInfo",
+                    "type": "string",
+                    "example": "MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="
+                },
+                "publicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                },
+                "threshold": {
+                    "description": "1 if empty",
+                    "type": "integer",
+                    "example": 1
+                },
+                "wrappedChainCode": {
+                    "description": "chain code wrapped with this node's wrapping key. base64. random if empty",
+                    "type": "string",
+                    "example": "base64"
+                },
+                "wrappedKeyShare": {
+                    "description": "this node's share wrapped with its wrapping key. base64",
+                    "type": "string",
+                    "example": "base64"
+                }
+            }
+        },
+        "handlers.PresignRequestBody": {
+            "type": "object",
+            "required": [
+                "count",
+                "keyId",
+                "publicKey",
+                "sessionId"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                }
+            }
+        },
+        "handlers.PublicKeyResponseBody": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "base58": {
+                    "type": "string",
+                    "example": "FQnyF8mUwgUapn3mjrcoCsURrmawEitKsMHkWfdYKtGP"
+                },
+                "base64": {
+                    "type": "string",
+                    "example": "1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="
+                },
+                "derivationPath": {
+                    "type": "string",
+                    "example": "m/44/501/0"
+                },
+                "hex": {
+                    "type": "string",
+                    "example": "d61bf425f83d54872146da73584ac7207735cfd1047fc77d9b8a10e86fcbc0e8"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "pkix": {
+                    "type": "string",
+                    "example": "MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="
+                }
+            }
+        },
+        "handlers.ReadyResponseBody": {
+            "type": "object",
+            "properties": {
+                "ready": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "handlers.RecoveryDataRequestBody": {
+            "type": "object",
+            "required": [
+                "ersPublicKey",
+                "sessionId"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "ersLabel": {
+                    "description": "OAEP label. optional",
+                    "type": "string",
+                    "example": "abc-tsm-recovery"
+                },
+                "ersPublicKey": {
+                    "description": "base64 SubjectPublicKeyInfo of the ERS RSA key",
+                    "type": "string",
+                    "example": "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                }
+            }
+        },
+        "handlers.RecoveryDataResponseBody": {
+            "type": "object",
+            "properties": {
+                "partialRecoveryData": {
+                    "description": "base64",
+                    "type": "string",
+                    "example": "eyJ..."
+                }
+            }
+        },
+        "handlers.ReshareKeyRequestBody": {
+            "type": "object",
+            "required": [
+                "keyId",
+                "publicKey",
+                "sessionId"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                }
+            }
+        },
+        "handlers.SignBatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/handlers.CommonErrorObject"
+                },
+                "signature": {
+                    "type": "string",
+                    "example": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
+                }
+            }
+        },
+        "handlers.SignBatchRequestBody": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handlers.SignRequestBody"
+                    }
+                }
+            }
+        },
+        "handlers.SignBatchResponseBody": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "description": "same order as the request items",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SignBatchItemResult"
+                    }
+                }
+            }
+        },
+        "handlers.SignRequestBody": {
+            "type": "object",
+            "required": [
+                "keyId",
+                "signSignatureId"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "schnorr (default) or ecdsa",
+                    "type": "string",
+                    "example": "schnorr"
+                },
+                "derivationPath": {
+                    "description": "non-hardened. master key if empty",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        44,
+                        501,
+                        0
+                    ]
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "message": {
+                    "description": "base64 raw message. message mode, schnorr only",
+                    "type": "string",
+                    "example": "SGVsbG8sIHdvcmxkIQ=="
+                },
+                "messageHash": {
+                    "description": "base64. hash mode",
+                    "type": "string",
+                    "example": "MV9b23bQeMQ7isAGTkoBZGErH853yGk0W/yUx1iU7dM="
+                },
+                "mode": {
+                    "description": "hash (default) or message",
+                    "type": "string",
+                    "example": "hash"
+                },
+                "signSignatureId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                }
+            }
+        },
+        "handlers.WrappingKeyResponseBody": {
+            "type": "object",
+            "properties": {
+                "wrappingKey": {
+                    "description": "base64 SubjectPublicKeyInfo of an RSA key",
+                    "type": "string",
+                    "example": "MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."
+                }
+            }
+        },
+        "main.RootResponse": {
+            "type": "object",
+            "properties": {
+                "build_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "presignature.Summary": {
+            "type": "object",
+            "properties": {
+                "availableCount": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "availableIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "consumedCount": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "consumedIds": {
+                    "description": "the most recently consumed IDs, up to MAX_CONSUMED_IDS",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                }
+            }
+        },
+        "service.KeyList": {
+            "type": "object",
+            "properties": {
+                "keyIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "session.Session": {
+            "type": "object",
+            "properties": {
+                "caller": {
+                    "type": "string",
+                    "example": "appserver"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deadline": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorText": {
+                    "type": "string",
+                    "example": "SESSION_FAILED"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "keyId": {
+                    "type": "string",
+                    "example": "zUhWR7jvWJoplMyFf35NHSdZXbtx"
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "generateKey"
+                },
+                "presignatureIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sessionId": {
+                    "type": "string",
+                    "example": "923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "succeeded"
                 }
             }
         }
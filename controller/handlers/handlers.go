@@ -1,16 +1,23 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"log"
 	"net/http"
+	"strconv"
 
+	"github.com/ahnlabio/tsm-controller/auth"
 	"github.com/ahnlabio/tsm-controller/service"
+	"github.com/ahnlabio/tsm-controller/tsmutils"
 	"github.com/gin-gonic/gin"
 )
 
 type GenerateKeyRequestBody struct {
 	SessionId string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
 	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	Algorithm string `json:"algorithm" example:"schnorr"` // schnorr (default) or ecdsa
+	Curve     string `json:"curve" example:"ED-25519"`    // default curve of the algorithm if empty
+	Threshold int    `json:"threshold" example:"1"`       // 1 if empty
 }
 
 type Handlers struct {
@@ -35,11 +42,11 @@ func (h *Handlers) GenerateKeyHandler(c *gin.Context) {
 	err := c.ShouldBind(&requestBody)
 	if err != nil {
 		log.Printf("[GenerateKeyHandler] c.ShouldBind Error: %v\n", err)
-		errResp(c, err)
+		errResp(c, service.InvalidInputError(err))
 		return
 	}
 
-	err = h.service.StartGenerateKeySession(requestBody.SessionId, requestBody.PublicKey)
+	err = h.service.StartGenerateKeySession(auth.Identity(c), requestBody.SessionId, requestBody.PublicKey, requestBody.Algorithm, requestBody.Curve, requestBody.Threshold)
 	if err != nil {
 		log.Printf("[GenerateKeyHandler] service.GenerateKey Error: %v\n", err)
 		errResp(c, err)
@@ -53,6 +60,9 @@ type CopyKeyRequestBody struct {
 	SessionId     string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
 	PublicKey     string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
 	ExistingKeyId string `json:"existingKeyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm     string `json:"algorithm" example:"schnorr"` // schnorr (default) or ecdsa
+	Curve         string `json:"curve" example:"ED-25519"`    // default curve of the algorithm if empty
+	Threshold     int    `json:"threshold" example:"1"`       // 1 if empty
 }
 
 // CopyKeyHandler godoc
@@ -69,11 +79,11 @@ func (h *Handlers) CopyKeyHandler(c *gin.Context) {
 	err := c.ShouldBind(&requestBody)
 	if err != nil {
 		log.Printf("[CopyKeyHandler] c.ShouldBind Error: %v\n", err)
-		errResp(c, err)
+		errResp(c, service.InvalidInputError(err))
 		return
 	}
 
-	err = h.service.StartCopyKeySession(requestBody.SessionId, requestBody.PublicKey, requestBody.ExistingKeyId)
+	err = h.service.StartCopyKeySession(auth.Identity(c), requestBody.SessionId, requestBody.PublicKey, requestBody.ExistingKeyId, requestBody.Algorithm, requestBody.Curve, requestBody.Threshold)
 	if err != nil {
 		log.Printf("[CopyKeyHandler] service.CopyKey Error: %v\n", err)
 		errResp(c, err)
@@ -83,11 +93,106 @@ func (h *Handlers) CopyKeyHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, "")
 }
 
+type ImportKeyRequestBody struct {
+	SessionId        string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	PublicKey        string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	WrappedKeyShare  string `json:"wrappedKeyShare" binding:"required" example:"base64"`                                                     // this node's share wrapped with its wrapping key. base64
+	WrappedChainCode string `json:"wrappedChainCode" example:"base64"`                                                                       // chain code wrapped with this node's wrapping key. base64. random if empty
+	PKIXPublicKey    string `json:"pkixPublicKey" binding:"required" example:"MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="` // public key of the imported key. base64 SubjectPublicKeyInfo
+	Algorithm        string `json:"algorithm" example:"schnorr"`                                                                             // schnorr (default) or ecdsa
+	Threshold        int    `json:"threshold" example:"1"`                                                                                   // 1 if empty
+}
+
+// ImportKeyHandler godoc
+// @Summary Start an import key session
+// @Description Import an existing private key secret-shared by the mobile player. The session fails unless the shares form the key of pkixPublicKey, so the imported key has the public key of the existing wallet.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Param body body ImportKeyRequestBody true "Wrapped key share and public key"
+// @Success 200
+// @Router /v1/importKey [post]
+func (h *Handlers) ImportKeyHandler(c *gin.Context) {
+	var requestBody ImportKeyRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[ImportKeyHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, service.InvalidInputError(err))
+		return
+	}
+
+	err = h.service.StartImportKeySession(auth.Identity(c), requestBody.SessionId, requestBody.PublicKey, requestBody.Algorithm, requestBody.Threshold, requestBody.WrappedKeyShare, requestBody.WrappedChainCode, requestBody.PKIXPublicKey)
+	if err != nil {
+		log.Printf("[ImportKeyHandler] service.ImportKey Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, "")
+}
+
+type WrappingKeyResponseBody struct {
+	WrappingKey string `json:"wrappingKey" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."` // base64 SubjectPublicKeyInfo of an RSA key
+}
+
+// WrappingKeyHandler godoc
+// @Summary Get the wrapping key of this node
+// @Description Get the RSA key the mobile player must wrap this node's key share with before an import
+// @Tags key
+// @Produce json
+// @Success 200 {object} WrappingKeyResponseBody
+// @Router /v1/wrappingKey [get]
+func (h *Handlers) WrappingKeyHandler(c *gin.Context) {
+	wrappingKey, err := h.service.WrappingKey(c.Request.Context())
+	if err != nil {
+		log.Printf("[WrappingKeyHandler] service.WrappingKey Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, WrappingKeyResponseBody{WrappingKey: base64.StdEncoding.EncodeToString(wrappingKey)})
+}
+
+type ReshareKeyRequestBody struct {
+	SessionId string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	KeyId     string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm string `json:"algorithm" example:"schnorr"` // schnorr (default) or ecdsa
+}
+
+// ReshareKeyHandler godoc
+// @Summary Start a reshare session
+// @Description Refresh the secret sharing of a key with all players. The public key and key ID are kept; old shares, backups of them and presignatures of the key become invalid.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Param body body ReshareKeyRequestBody true "Public key and key ID"
+// @Success 200
+// @Router /v1/reshareKey [post]
+func (h *Handlers) ReshareKeyHandler(c *gin.Context) {
+	var requestBody ReshareKeyRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[ReshareKeyHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, service.InvalidInputError(err))
+		return
+	}
+
+	err = h.service.StartReshareSession(auth.Identity(c), requestBody.SessionId, requestBody.PublicKey, requestBody.KeyId, requestBody.Algorithm)
+	if err != nil {
+		log.Printf("[ReshareKeyHandler] service.Reshare Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, "")
+}
+
 type PresignRequestBody struct {
 	SessionId string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
 	PublicKey string `json:"publicKey" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
 	KeyId     string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
 	Count     uint64 `json:"count" binding:"required" example:"3"`
+	Algorithm string `json:"algorithm" example:"schnorr"` // schnorr (default) or ecdsa
 }
 
 // PreSignHandler godoc
@@ -98,17 +203,17 @@ type PresignRequestBody struct {
 // @Produce json
 // @Param body body PresignRequestBody true "Public key"
 // @Success 200
-// @Router /v1/presign [post]
+// @Router /v1/preSign [post]
 func (h *Handlers) PreSignHandler(c *gin.Context) {
 	var requestBody PresignRequestBody
 	err := c.ShouldBind(&requestBody)
 	if err != nil {
 		log.Printf("[PreSignHandler] c.ShouldBind Error: %v\n", err)
-		errResp(c, err)
+		errResp(c, service.InvalidInputError(err))
 		return
 	}
 
-	err = h.service.StartPresignSession(requestBody.SessionId, requestBody.PublicKey, requestBody.KeyId, requestBody.Count)
+	err = h.service.StartPresignSession(auth.Identity(c), requestBody.SessionId, requestBody.PublicKey, requestBody.KeyId, requestBody.Count, requestBody.Algorithm)
 	if err != nil {
 		log.Printf("[PreSignHandler] service.PreSign Error: %v\n", err)
 		errResp(c, err)
@@ -119,9 +224,13 @@ func (h *Handlers) PreSignHandler(c *gin.Context) {
 }
 
 type SignRequestBody struct {
-	SignSignatureId string `json:"signSignatureId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
-	MessageHash     string `json:"messageHash" binding:"required" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
-	KeyId           string `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	SignSignatureId string   `json:"signSignatureId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	Mode            string   `json:"mode" example:"hash"`                                                // hash (default) or message
+	MessageHash     string   `json:"messageHash" example:"MV9b23bQeMQ7isAGTkoBZGErH853yGk0W/yUx1iU7dM="` // base64. hash mode
+	Message         string   `json:"message" example:"SGVsbG8sIHdvcmxkIQ=="`                             // base64 raw message. message mode, schnorr only
+	KeyId           string   `json:"keyId" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm       string   `json:"algorithm" example:"schnorr"`       // schnorr (default) or ecdsa
+	DerivationPath  []uint32 `json:"derivationPath" example:"44,501,0"` // non-hardened. master key if empty
 }
 
 type SignResponseBody struct {
@@ -136,17 +245,17 @@ type SignResponseBody struct {
 // @Produce json
 // @Param body body SignRequestBody true "Public key"
 // @Success 200
-// @Router /v1/sign [post]
+// @Router /v1/partialSign [post]
 func (h *Handlers) PartialSignHandler(c *gin.Context) {
 	var requestBody SignRequestBody
 	err := c.ShouldBind(&requestBody)
 	if err != nil {
 		log.Printf("[SignHandler] c.ShouldBind Error: %v\n", err)
-		errResp(c, err)
+		errResp(c, service.InvalidInputError(err))
 		return
 	}
 
-	signature, err := h.service.PartialSign(requestBody.SignSignatureId, requestBody.MessageHash, requestBody.KeyId)
+	signature, err := h.service.PartialSign(c.Request.Context(), auth.Identity(c), requestBody.SignSignatureId, requestBody.Mode, requestBody.MessageHash, requestBody.Message, requestBody.KeyId, requestBody.Algorithm, requestBody.DerivationPath)
 	if err != nil {
 		log.Printf("[SignHandler] service.Sign Error: %v\n", err)
 		errResp(c, err)
@@ -155,6 +264,330 @@ func (h *Handlers) PartialSignHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, SignResponseBody{Signature: signature})
 }
 
+type SignBatchRequestBody struct {
+	Items []SignRequestBody `json:"items" binding:"required,min=1,max=100,dive"`
+}
+
+type SignBatchItemResult struct {
+	Signature string             `json:"signature,omitempty" example:"MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="`
+	Error     *CommonErrorObject `json:"error,omitempty"`
+}
+
+type SignBatchResponseBody struct {
+	Results []SignBatchItemResult `json:"results"` // same order as the request items
+}
+
+// PartialSignBatchHandler godoc
+// @Summary Partial sign a batch of messages
+// @Description Partial sign up to 100 (presignatureId, messageHash, keyId) items in one request. Items are signed in order and independently: a failed item does not stop or roll back the others and its error is returned in place of the signature with the same text as /v1/partialSign. The request fails as a whole (400) only if the body or an item is malformed.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Param body body SignBatchRequestBody true "Sign items"
+// @Success 200 {object} SignBatchResponseBody
+// @Failure 400 {object} CommonErrorObject
+// @Router /v1/partialSignBatch [post]
+func (h *Handlers) PartialSignBatchHandler(c *gin.Context) {
+	var requestBody SignBatchRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[PartialSignBatchHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, service.InvalidInputError(err))
+		return
+	}
+
+	items := make([]service.PartialSignItem, len(requestBody.Items))
+	for i, item := range requestBody.Items {
+		items[i] = service.PartialSignItem{
+			PreSignatureId: item.SignSignatureId,
+			Mode:           item.Mode,
+			MessageHash:    item.MessageHash,
+			Message:        item.Message,
+			KeyId:          item.KeyId,
+			Algorithm:      item.Algorithm,
+			DerivationPath: item.DerivationPath,
+		}
+	}
+
+	results, err := h.service.PartialSignBatch(c.Request.Context(), auth.Identity(c), items)
+	if err != nil {
+		log.Printf("[PartialSignBatchHandler] service.PartialSignBatch Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+
+	responseBody := SignBatchResponseBody{Results: make([]SignBatchItemResult, len(results))}
+	for i, result := range results {
+		if result.Err != nil {
+			log.Printf("[PartialSignBatchHandler] item: %d, error: %v\n", i, result.Err)
+			responseBody.Results[i].Error = errObject(result.Err)
+			continue
+		}
+		responseBody.Results[i].Signature = result.Signature
+	}
+	c.JSON(http.StatusOK, responseBody)
+}
+
+type PublicKeyResponseBody struct {
+	KeyId          string `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	Algorithm      string `json:"algorithm" example:"schnorr"`
+	DerivationPath string `json:"derivationPath" example:"m/44/501/0"`
+	*tsmutils.PublicKeyInfo
+}
+
+// PublicKeyHandler godoc
+// @Summary Get the public key of a key
+// @Description Get the public key of a key, optionally derived with a non-hardened BIP32 path. hex, base64 and base58 are encodings of the raw public key.
+// @Tags key
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Param algorithm query string false "schnorr (default) or ecdsa"
+// @Param derivationPath query string false "non-hardened derivation path. e.g. m/44/501/0"
+// @Success 200 {object} PublicKeyResponseBody
+// @Failure 400 {object} CommonErrorObject
+// @Router /v1/keys/{keyId}/publicKey [get]
+func (h *Handlers) PublicKeyHandler(c *gin.Context) {
+	keyId := c.Param("keyId")
+	algorithm := c.Query("algorithm")
+	derivationPath := c.Query("derivationPath")
+
+	publicKey, err := h.service.PublicKey(c.Request.Context(), keyId, algorithm, derivationPath)
+	if err != nil {
+		log.Printf("[PublicKeyHandler] service.PublicKey Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PublicKeyResponseBody{
+		KeyId:          keyId,
+		Algorithm:      publicKey.Algorithm,
+		DerivationPath: derivationPath,
+		PublicKeyInfo:  publicKey.PublicKeyInfo,
+	})
+}
+
+type ReadyResponseBody struct {
+	Ready bool `json:"ready" example:"true"`
+}
+
+// ReadyHandler godoc
+// @Summary Check readiness
+// @Description Report whether the MPC node passed its last health check. Returns 503 until it does.
+// @Tags info
+// @Produce json
+// @Success 200 {object} ReadyResponseBody
+// @Failure 503 {object} ReadyResponseBody
+// @Router /ready [get]
+func (h *Handlers) ReadyHandler(c *gin.Context) {
+	if !h.service.Ready() {
+		c.JSON(http.StatusServiceUnavailable, ReadyResponseBody{Ready: false})
+		return
+	}
+	c.JSON(http.StatusOK, ReadyResponseBody{Ready: true})
+}
+
+// ListKeysHandler godoc
+// @Summary List keys
+// @Description List the keys this node holds a share of, sorted by key ID
+// @Tags key
+// @Produce json
+// @Param offset query int false "offset" default(0)
+// @Param limit query int false "limit. max 1000" default(100)
+// @Success 200 {object} service.KeyList
+// @Failure 400 {object} CommonErrorObject
+// @Router /v1/keys [get]
+func (h *Handlers) ListKeysHandler(c *gin.Context) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		errResp(c, service.InvalidInputError(err))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		errResp(c, service.InvalidInputError(err))
+		return
+	}
+
+	keyList, err := h.service.ListKeys(c.Request.Context(), offset, limit)
+	if err != nil {
+		log.Printf("[ListKeysHandler] service.ListKeys Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keyList)
+}
+
+// DeleteKeyHandler godoc
+// @Summary Delete a key share
+// @Description Delete this node's share of a key and its presignatures
+// @Tags key
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Success 200
+// @Router /v1/keys/{keyId} [delete]
+func (h *Handlers) DeleteKeyHandler(c *gin.Context) {
+	keyId := c.Param("keyId")
+
+	err := h.service.DeleteKey(c.Request.Context(), auth.Identity(c), keyId)
+	if err != nil {
+		log.Printf("[DeleteKeyHandler] service.DeleteKey Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, "")
+}
+
+type BackupKeyShareRequestBody struct {
+	PublicKey string `json:"publicKey" binding:"required" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."` // base64 SubjectPublicKeyInfo of an operator RSA key (2048 bits or more)
+	Algorithm string `json:"algorithm" example:"schnorr"`                                                            // schnorr (default) or ecdsa
+}
+
+// BackupKeyShareHandler godoc
+// @Summary Export an encrypted backup of this node's key share
+// @Description Export this node's share of a key encrypted with RSA-OAEP-256 and AES-256-GCM to an operator RSA public key. The key must be listed in BACKUP_RECIPIENT_FINGERPRINTS. Decrypt it with cmd/backupdecrypt.
+// @Tags key
+// @Accept json
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Param body body BackupKeyShareRequestBody true "Operator public key"
+// @Success 200 {object} backup.Envelope
+// @Failure 400 {object} CommonErrorObject
+// @Failure 403 {object} CommonErrorObject
+// @Failure 404 {object} CommonErrorObject
+// @Router /v1/keys/{keyId}/backup [post]
+func (h *Handlers) BackupKeyShareHandler(c *gin.Context) {
+	var requestBody BackupKeyShareRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[BackupKeyShareHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, service.InvalidInputError(err))
+		return
+	}
+
+	envelope, err := h.service.BackupKeyShare(c.Request.Context(), auth.Identity(c), c.Param("keyId"), requestBody.Algorithm, requestBody.PublicKey)
+	if err != nil {
+		log.Printf("[BackupKeyShareHandler] service.BackupKeyShare Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, envelope)
+}
+
+type RecoveryDataRequestBody struct {
+	SessionId    string `json:"sessionId" binding:"required" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	ERSPublicKey string `json:"ersPublicKey" binding:"required" example:"MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA..."` // base64 SubjectPublicKeyInfo of the ERS RSA key
+	ERSLabel     string `json:"ersLabel" example:"abc-tsm-recovery"`                                                       // OAEP label. optional
+	Algorithm    string `json:"algorithm" example:"schnorr"`                                                               // schnorr (default) or ecdsa
+}
+
+type RecoveryDataResponseBody struct {
+	PartialRecoveryData string `json:"partialRecoveryData" example:"eyJ..."` // base64
+}
+
+// RecoveryDataHandler godoc
+// @Summary Generate partial ERS recovery data
+// @Description Run an ERS session with the other server player and return this player's partial recovery data, encrypted to the ERS public key. The ERS key must be listed in ERS_RECIPIENT_FINGERPRINTS. The appserver must call both players with the same session ID, ERS key and label. Fails with SESSION_TIMEOUT if the other player doesn't join within ERS_TIMEOUT.
+// @Tags key
+// @Accept json
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Param body body RecoveryDataRequestBody true "Session ID and ERS public key"
+// @Success 200 {object} RecoveryDataResponseBody
+// @Failure 400 {object} CommonErrorObject
+// @Failure 403 {object} CommonErrorObject
+// @Failure 504 {object} CommonErrorObject
+// @Router /v1/keys/{keyId}/recoveryData [post]
+func (h *Handlers) RecoveryDataHandler(c *gin.Context) {
+	var requestBody RecoveryDataRequestBody
+	err := c.ShouldBind(&requestBody)
+	if err != nil {
+		log.Printf("[RecoveryDataHandler] c.ShouldBind Error: %v\n", err)
+		errResp(c, service.InvalidInputError(err))
+		return
+	}
+
+	partialRecoveryData, err := h.service.GenerateRecoveryData(c.Request.Context(), auth.Identity(c), requestBody.SessionId, c.Param("keyId"), requestBody.Algorithm, requestBody.ERSPublicKey, requestBody.ERSLabel)
+	if err != nil {
+		log.Printf("[RecoveryDataHandler] service.GenerateRecoveryData Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, RecoveryDataResponseBody{PartialRecoveryData: base64.StdEncoding.EncodeToString(partialRecoveryData)})
+}
+
+// GetPresignaturesHandler godoc
+// @Summary Get presignatures of a key
+// @Description Get the presignature IDs generated on this node for a key and how many of them are consumed. Only the 100 most recently consumed IDs are listed. presign runs on player 1 only.
+// @Tags key
+// @Produce json
+// @Param keyId path string true "Key ID"
+// @Success 200 {object} presignature.Summary
+// @Router /v1/keys/{keyId}/presignatures [get]
+func (h *Handlers) GetPresignaturesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.GetPresignatures(c.Param("keyId")))
+}
+
+// GetSessionHandler godoc
+// @Summary Get a session status
+// @Description Get the status and result of a keygen, copy, reshare, import or presign session started on this node
+// @Tags session
+// @Produce json
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} session.Session
+// @Failure 404 {object} CommonErrorObject
+// @Router /v1/sessions/{sessionId} [get]
+func (h *Handlers) GetSessionHandler(c *gin.Context) {
+	sessionId := c.Param("sessionId")
+
+	result, err := h.service.GetSession(sessionId)
+	if err != nil {
+		log.Printf("[GetSessionHandler] service.GetSession Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CancelSessionHandler godoc
+// @Summary Cancel a session
+// @Description Cancel a pending or running keygen, copy, reshare, import or presign session so the node stops waiting for the mobile player
+// @Tags session
+// @Produce json
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} session.Session
+// @Failure 404 {object} CommonErrorObject
+// @Failure 409 {object} CommonErrorObject
+// @Router /v1/sessions/{sessionId}/cancel [post]
+func (h *Handlers) CancelSessionHandler(c *gin.Context) {
+	sessionId := c.Param("sessionId")
+
+	result, err := h.service.CancelSession(auth.Identity(c), sessionId)
+	if err != nil {
+		log.Printf("[CancelSessionHandler] service.CancelSession Error: %v\n", err)
+		errResp(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportAuditHandler godoc
+// @Summary Export the audit log
+// @Description Download the hash-chained audit log of key operations as JSON lines. Verify it with cmd/auditverify.
+// @Tags audit
+// @Produce json
+// @Success 200 {array} audit.Entry
+// @Router /v1/audit/export [get]
+func (h *Handlers) ExportAuditHandler(c *gin.Context) {
+	log.Printf("[ExportAuditHandler] caller: %s", auth.Identity(c))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", "attachment; filename=audit.jsonl")
+	c.Status(http.StatusOK)
+	if err := h.service.ExportAudit(c.Writer); err != nil {
+		// 이미 응답을 쓰기 시작했으므로 status 를 바꿀 수 없습니다.
+		log.Printf("[ExportAuditHandler] service.ExportAudit Error: %v\n", err)
+	}
+}
+
 func errResp(c *gin.Context, err error) {
 	if errorInfo, ok := err.(*service.SvcErr); ok {
 		res := CommonErrorObject{
@@ -163,8 +596,21 @@ func errResp(c *gin.Context, err error) {
 		}
 
 		status := http.StatusInternalServerError
-		if errorInfo.Text == service.INVALID_INPUT {
+		switch errorInfo.Text {
+		case service.INVALID_INPUT:
 			status = http.StatusBadRequest
+		case service.SESSION_NOT_FOUND, service.KEY_NOT_FOUND:
+			status = http.StatusNotFound
+		case service.WRONG_ROLE, service.POLICY_DENIED:
+			status = http.StatusForbidden
+		case service.NODE_UNAVAILABLE, service.AUDIT_FAILED:
+			status = http.StatusServiceUnavailable
+		case service.SESSION_FAILED:
+			status = http.StatusInternalServerError
+		case service.SESSION_TIMEOUT:
+			status = http.StatusGatewayTimeout
+		case service.SESSION_FINISHED:
+			status = http.StatusConflict
 		}
 
 		log.Printf("[ERROR] err: %s, url: %s, status: %d\n", err.Error(), c.Request.URL, status)
@@ -176,3 +622,10 @@ func errResp(c *gin.Context, err error) {
 	}
 	c.JSON(http.StatusInternalServerError, gin.H{"error": &res})
 }
+
+func errObject(err error) *CommonErrorObject {
+	if errorInfo, ok := err.(*service.SvcErr); ok {
+		return &CommonErrorObject{Message: errorInfo.Msg, Text: errorInfo.Text}
+	}
+	return &CommonErrorObject{Message: err.Error()}
+}
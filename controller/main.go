@@ -10,12 +10,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ahnlabio/tsm-controller/auth"
 	"github.com/ahnlabio/tsm-controller/config"
 	"github.com/ahnlabio/tsm-controller/container"
+	"github.com/ahnlabio/tsm-controller/docs"
+	"github.com/ahnlabio/tsm-controller/metrics"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"github.com/swaggo/swag/example/basic/docs"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -25,39 +27,101 @@ func main() {
 	godotenv.Load()
 	swagInit()
 	router := getRouter()
-	addMiddlewares(router)
+	reloadPolicyOnSignal()
 	runServerApplication(router)
 }
 
+// reloadPolicyOnSignal 은 SIGHUP 을 받으면 POLICY_FILE 을 다시 읽습니다.
+func reloadPolicyOnSignal() {
+	tsmService := container.GetInstnace().TsmService
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := tsmService.ReloadPolicy(); err != nil {
+				log.Printf("[ERROR] failed to reload policy: %v", err)
+			}
+		}
+	}()
+}
+
 func getRouter() *gin.Engine {
 	r := gin.Default()
+	// middleware 는 route 를 등록하기 전에 추가해야 적용됩니다.
+	addMiddlewares(r)
 
-	handlers := container.GetInstnace().GetHandlers()
+	appContainer := container.GetInstnace()
+	handlers := appContainer.GetHandlers()
 	r.GET("/", rootHandler)
+	// load balancer 나 kubernetes readiness probe 가 인증 없이 호출합니다.
+	r.GET("/ready", handlers.ReadyHandler)
 	r.GET("/swagger/*any", func(c *gin.Context) {
 		ginSwagger.WrapHandler(swaggerFiles.Handler)(c)
 	})
-	r.POST("/v1/generateKey", handlers.GenerateKeyHandler)
-	r.POST("/v1/copyKey", handlers.CopyKeyHandler)
-	r.POST("/v1/preSign", handlers.PreSignHandler)
-	r.POST("/v1/partialSign", handlers.PartialSignHandler)
+	// prometheus 가 수집합니다. key 나 session 정보는 포함하지 않습니다.
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// /v1 은 appserver 만 호출할 수 있습니다.
+	v1 := r.Group("/v1", auth.Middleware(appContainer.GetAuthenticator()))
+	v1.POST("/generateKey", handlers.GenerateKeyHandler)
+	v1.POST("/copyKey", handlers.CopyKeyHandler)
+	v1.POST("/reshareKey", handlers.ReshareKeyHandler)
+	v1.POST("/importKey", handlers.ImportKeyHandler)
+	v1.GET("/wrappingKey", handlers.WrappingKeyHandler)
+	v1.POST("/preSign", handlers.PreSignHandler)
+	v1.POST("/partialSign", handlers.PartialSignHandler)
+	v1.POST("/partialSignBatch", handlers.PartialSignBatchHandler)
+	v1.GET("/sessions/:sessionId", handlers.GetSessionHandler)
+	v1.POST("/sessions/:sessionId/cancel", handlers.CancelSessionHandler)
+	v1.GET("/keys", handlers.ListKeysHandler)
+	v1.DELETE("/keys/:keyId", handlers.DeleteKeyHandler)
+	v1.GET("/keys/:keyId/publicKey", handlers.PublicKeyHandler)
+	v1.GET("/keys/:keyId/presignatures", handlers.GetPresignaturesHandler)
+	v1.POST("/keys/:keyId/backup", handlers.BackupKeyShareHandler)
+	v1.POST("/keys/:keyId/recoveryData", handlers.RecoveryDataHandler)
+	v1.GET("/audit/export", handlers.ExportAuditHandler)
 
 	return r
 }
 
 func addMiddlewares(r *gin.Engine) {
-	r.Use(cors.Default())
+	// controller 는 server 간 호출만 받으므로 허용된 origin 이 설정된 경우에만 CORS 를 허용합니다.
+	allowedOrigins := config.SplitList(config.GetConfig().CORSAllowedOrigins)
+	if len(allowedOrigins) == 0 {
+		return
+	}
+
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = allowedOrigins
+	corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, auth.HEADER_KEY_ID, auth.HEADER_TIMESTAMP, auth.HEADER_NONCE, auth.HEADER_SIGNATURE)
+	r.Use(cors.New(corsConfig))
 }
 
 func runServerApplication(router *gin.Engine) {
+	appConfig := config.GetConfig()
 	srv := &http.Server{
 		Addr:    ":3000",
 		Handler: router.Handler(),
 	}
 
+	if appConfig.TLSCertFile != "" {
+		// TLS_CLIENT_CA_FILE 이 설정되면 client certificate 를 요구합니다. (AUTH_MODE=mtls)
+		tlsConfig, err := auth.ServerTLSConfig(appConfig.TLSClientCAFile)
+		if err != nil {
+			log.Fatalf("tls config: %s\n", err)
+		}
+		srv.TLSConfig = tlsConfig
+	}
+
 	go func() {
 		// service connections
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if appConfig.TLSCertFile != "" {
+			err = srv.ListenAndServeTLS(appConfig.TLSCertFile, appConfig.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("listen: %s\n", err)
 		}
 	}()
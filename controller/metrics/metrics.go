@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const NAMESPACE string = "tsm_controller"
+
+// PARTIAL_SIGN 은 session 이 없는 operation 입니다. session operation 은 session 패키지의 이름을 사용합니다.
+const PARTIAL_SIGN string = "partialSign"
+
+const (
+	SUCCEEDED string = "succeeded"
+	FAILED    string = "failed"
+	CANCELLED string = "cancelled"
+	TIMEOUT   string = "timeout"
+	DENIED    string = "denied"
+)
+
+// MPC session 은 mobile player 를 기다리므로 수 분까지 걸릴 수 있습니다.
+var durationBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Metrics counts operations of this node. Every metric has the player_index of the node as a label.
+type Metrics struct {
+	operations *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+}
+
+// New registers the metrics. inFlight returns the number of unfinished background sessions per operation.
+func New(registerer prometheus.Registerer, playerIndex string, inFlight func() map[string]int) *Metrics {
+	constLabels := prometheus.Labels{"player_index": playerIndex}
+	m := &Metrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   NAMESPACE,
+			Name:        "operations_total",
+			Help:        "Number of finished operations by outcome.",
+			ConstLabels: constLabels,
+		}, []string{"operation", "outcome"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   NAMESPACE,
+			Name:        "operation_duration_seconds",
+			Help:        "Time from the request to the completion of the SDK call.",
+			ConstLabels: constLabels,
+			Buckets:     durationBuckets,
+		}, []string{"operation", "outcome"}),
+	}
+	registerer.MustRegister(m.operations, m.duration, &sessionCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(NAMESPACE, "", "sessions_in_flight"),
+			"Number of pending or running background sessions.",
+			[]string{"operation"},
+			constLabels,
+		),
+		inFlight: inFlight,
+	})
+	return m
+}
+
+func (m *Metrics) Observe(operation string, outcome string, duration time.Duration) {
+	m.operations.WithLabelValues(operation, outcome).Inc()
+	m.duration.WithLabelValues(operation, outcome).Observe(duration.Seconds())
+}
+
+// Handler serves the metrics of the default registry, including go runtime and process metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// sessionCollector 는 scrape 할 때 session registry 에서 진행 중인 session 수를 읽습니다.
+type sessionCollector struct {
+	desc     *prometheus.Desc
+	inFlight func() map[string]int
+}
+
+func (c *sessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *sessionCollector) Collect(ch chan<- prometheus.Metric) {
+	for operation, count := range c.inFlight() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), operation)
+	}
+}
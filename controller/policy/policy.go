@@ -0,0 +1,335 @@
+package policy
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	POLICY_DENIED string = "POLICY_DENIED"
+)
+
+var (
+	ErrDenied = errors.New(POLICY_DENIED)
+)
+
+// Request is a partial signature request to be authorized.
+type Request struct {
+	KeyId       string
+	MessageHash []byte
+	Time        time.Time
+}
+
+// Policy decides whether a partial signature may be produced.
+// Authorize counts the request against the limits when it is allowed, so concurrent requests can't exceed them.
+// Refund takes back an allowed request whose signature was not produced, e.g. for an unknown key.
+type Policy interface {
+	Authorize(request Request) error
+	Refund(request Request)
+}
+
+// Config is the policy file.
+//
+//	{
+//	  "timezone": "Asia/Seoul",
+//	  "default": {"ratePerMinute": 10, "dailyCap": 500, "windows": [{"days": ["mon", "tue"], "start": "09:00", "end": "18:00"}]},
+//	  "keys": {"zUhWR7jvWJoplMyFf35NHSdZXbtx": {"ratePerMinute": 1, "dailyCap": 10}},
+//	  "deniedMessageHashes": ["<hex of a message hash>"]
+//	}
+//
+// In message sign mode the deny-list is checked against sha256 of the message.
+type Config struct {
+	Timezone            string          `json:"timezone"`
+	Default             Rule            `json:"default"`
+	Keys                map[string]Rule `json:"keys"`
+	DeniedMessageHashes []string        `json:"deniedMessageHashes"`
+}
+
+// Rule limits the partial signatures of a key. Zero values mean no limit.
+// A key listed in Config.Keys uses its own rule instead of the default.
+type Rule struct {
+	RatePerMinute int      `json:"ratePerMinute"`
+	DailyCap      int      `json:"dailyCap"`
+	Windows       []Window `json:"windows"` // empty means any time
+}
+
+// Window is a time range of allowed days. end before start spans midnight.
+type Window struct {
+	Days  []string `json:"days"` // mon, tue, ... empty means every day
+	Start string   `json:"start" example:"09:00"`
+	End   string   `json:"end" example:"18:00"`
+}
+
+// LoadFile reads and validates a policy file.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	if _, err := compile(&config); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Engine evaluates a Config. Usage counters survive Reload.
+type Engine struct {
+	mu       sync.Mutex
+	compiled *compiledConfig
+	usage    map[string]*usage
+}
+
+func NewEngine(config *Config) (*Engine, error) {
+	compiled, err := compile(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{compiled: compiled, usage: make(map[string]*usage)}, nil
+}
+
+// Reload replaces the policy. Requests already counted stay counted.
+func (e *Engine) Reload(config *Config) error {
+	compiled, err := compile(config)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.compiled = compiled
+	return nil
+}
+
+func (e *Engine) Authorize(request Request) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	config := e.compiled
+	if config.deniedHashes[hex.EncodeToString(request.MessageHash)] {
+		return fmt.Errorf("%w: message hash is deny-listed", ErrDenied)
+	}
+
+	rule := config.rule(request.KeyId)
+	now := request.Time.In(config.location)
+
+	if len(rule.windows) > 0 && !inWindows(rule.windows, now) {
+		return fmt.Errorf("%w: signing is not allowed at %s", ErrDenied, now.Format("Mon 15:04 MST"))
+	}
+
+	u, ok := e.usage[request.KeyId]
+	if !ok {
+		u = &usage{}
+		e.usage[request.KeyId] = u
+	}
+	u.prune(now)
+
+	if rule.RatePerMinute > 0 && len(u.lastMinute) >= rule.RatePerMinute {
+		return fmt.Errorf("%w: rate limit of %d signatures per minute exceeded for key %s", ErrDenied, rule.RatePerMinute, request.KeyId)
+	}
+	if rule.DailyCap > 0 && u.dailyCount >= rule.DailyCap {
+		return fmt.Errorf("%w: daily cap of %d signatures reached for key %s", ErrDenied, rule.DailyCap, request.KeyId)
+	}
+
+	u.lastMinute = append(u.lastMinute, now)
+	u.dailyCount++
+	return nil
+}
+
+// Refund removes a request counted by Authorize.
+// The usage of a key is dropped when nothing is counted, so requests for keys that don't exist leave no entry.
+func (e *Engine) Refund(request Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	u, ok := e.usage[request.KeyId]
+	if !ok {
+		return
+	}
+	now := request.Time.In(e.compiled.location)
+	for i := len(u.lastMinute) - 1; i >= 0; i-- {
+		if u.lastMinute[i].Equal(now) {
+			u.lastMinute = append(u.lastMinute[:i], u.lastMinute[i+1:]...)
+			break
+		}
+	}
+	// 날짜가 바뀐 뒤에는 이미 초기화된 count 를 줄이지 않습니다.
+	if u.day == now.Format("2006-01-02") && u.dailyCount > 0 {
+		u.dailyCount--
+	}
+	if len(u.lastMinute) == 0 && u.dailyCount == 0 {
+		delete(e.usage, request.KeyId)
+	}
+}
+
+// AllowAll is used when no policy file is configured.
+type AllowAll struct{}
+
+func (AllowAll) Authorize(request Request) error {
+	return nil
+}
+
+func (AllowAll) Refund(request Request) {}
+
+type usage struct {
+	lastMinute []time.Time
+	day        string
+	dailyCount int
+}
+
+func (u *usage) prune(now time.Time) {
+	cutoff := now.Add(-time.Minute)
+	i := 0
+	for i < len(u.lastMinute) && !u.lastMinute[i].After(cutoff) {
+		i++
+	}
+	u.lastMinute = u.lastMinute[i:]
+
+	// 하루는 policy timezone 의 날짜 기준입니다.
+	day := now.Format("2006-01-02")
+	if u.day != day {
+		u.day = day
+		u.dailyCount = 0
+	}
+}
+
+type compiledConfig struct {
+	location     *time.Location
+	defaultRule  compiledRule
+	keys         map[string]compiledRule
+	deniedHashes map[string]bool
+}
+
+func (c *compiledConfig) rule(keyId string) compiledRule {
+	if rule, ok := c.keys[keyId]; ok {
+		return rule
+	}
+	return c.defaultRule
+}
+
+type compiledRule struct {
+	RatePerMinute int
+	DailyCap      int
+	windows       []compiledWindow
+}
+
+type compiledWindow struct {
+	days  map[time.Weekday]bool
+	start int // minutes from midnight
+	end   int
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func compile(config *Config) (*compiledConfig, error) {
+	location := time.UTC
+	if config.Timezone != "" {
+		loaded, err := time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		location = loaded
+	}
+
+	defaultRule, err := compileRule(config.Default)
+	if err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+
+	keys := make(map[string]compiledRule, len(config.Keys))
+	for keyId, rule := range config.Keys {
+		compiledRule, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", keyId, err)
+		}
+		keys[keyId] = compiledRule
+	}
+
+	deniedHashes := make(map[string]bool, len(config.DeniedMessageHashes))
+	for _, messageHash := range config.DeniedMessageHashes {
+		messageHash = strings.ToLower(strings.TrimSpace(messageHash))
+		if _, err := hex.DecodeString(messageHash); err != nil {
+			return nil, fmt.Errorf("deniedMessageHashes: %s is not hex", messageHash)
+		}
+		deniedHashes[messageHash] = true
+	}
+
+	return &compiledConfig{location: location, defaultRule: defaultRule, keys: keys, deniedHashes: deniedHashes}, nil
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	if rule.RatePerMinute < 0 || rule.DailyCap < 0 {
+		return compiledRule{}, fmt.Errorf("ratePerMinute and dailyCap must be >= 0")
+	}
+
+	windows := make([]compiledWindow, len(rule.Windows))
+	for i, window := range rule.Windows {
+		days := make(map[time.Weekday]bool)
+		for _, day := range window.Days {
+			weekday, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				return compiledRule{}, fmt.Errorf("invalid day: %s", day)
+			}
+			days[weekday] = true
+		}
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		windows[i] = compiledWindow{days: days, start: start, end: end}
+	}
+
+	return compiledRule{RatePerMinute: rule.RatePerMinute, DailyCap: rule.DailyCap, windows: windows}, nil
+}
+
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q. use HH:MM", value)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+func inWindows(windows []compiledWindow, now time.Time) bool {
+	minutes := now.Hour()*60 + now.Minute()
+	for _, window := range windows {
+		if window.start <= window.end {
+			if window.allows(now.Weekday()) && minutes >= window.start && minutes < window.end {
+				return true
+			}
+			continue
+		}
+		// 자정을 넘는 window 는 시작한 날의 요일을 기준으로 합니다.
+		if window.allows(now.Weekday()) && minutes >= window.start {
+			return true
+		}
+		if window.allows((now.Weekday()+6)%7) && minutes < window.end {
+			return true
+		}
+	}
+	return false
+}
+
+func (w compiledWindow) allows(weekday time.Weekday) bool {
+	return len(w.days) == 0 || w.days[weekday]
+}
@@ -0,0 +1,169 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newEngine(t *testing.T, config Config) *Engine {
+	t.Helper()
+	engine, err := NewEngine(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+// monday 10:00 UTC
+var monday = time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+
+func TestRatePerMinute(t *testing.T) {
+	engine := newEngine(t, Config{Default: Rule{RatePerMinute: 2}})
+
+	for i := 0; i < 2; i++ {
+		if err := engine.Authorize(Request{KeyId: "k", Time: monday.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("request %d error: %v", i, err)
+		}
+	}
+	if err := engine.Authorize(Request{KeyId: "k", Time: monday.Add(2 * time.Second)}); !errors.Is(err, ErrDenied) {
+		t.Errorf("third request error = %v, want %v", err, ErrDenied)
+	}
+	// 다른 key 는 따로 계산합니다.
+	if err := engine.Authorize(Request{KeyId: "other", Time: monday.Add(2 * time.Second)}); err != nil {
+		t.Errorf("other key error: %v", err)
+	}
+	if err := engine.Authorize(Request{KeyId: "k", Time: monday.Add(time.Minute + time.Second)}); err != nil {
+		t.Errorf("request after a minute error: %v", err)
+	}
+}
+
+func TestDailyCapResetsOnPolicyDay(t *testing.T) {
+	engine := newEngine(t, Config{Timezone: "Asia/Seoul", Default: Rule{DailyCap: 1}})
+
+	// 2024-01-01 23:00 KST
+	evening := time.Date(2024, time.January, 1, 14, 0, 0, 0, time.UTC)
+	if err := engine.Authorize(Request{KeyId: "k", Time: evening}); err != nil {
+		t.Fatalf("first request error: %v", err)
+	}
+	if err := engine.Authorize(Request{KeyId: "k", Time: evening.Add(30 * time.Minute)}); !errors.Is(err, ErrDenied) {
+		t.Errorf("request over the cap error = %v, want %v", err, ErrDenied)
+	}
+	// 2024-01-02 00:30 KST 는 UTC 로는 아직 1일이지만 policy timezone 의 다음 날입니다.
+	if err := engine.Authorize(Request{KeyId: "k", Time: evening.Add(90 * time.Minute)}); err != nil {
+		t.Errorf("request on the next day error: %v", err)
+	}
+}
+
+func TestKeyRuleOverridesDefault(t *testing.T) {
+	engine := newEngine(t, Config{
+		Default: Rule{DailyCap: 1},
+		Keys:    map[string]Rule{"vip": {DailyCap: 3}},
+	})
+	for i := 0; i < 3; i++ {
+		if err := engine.Authorize(Request{KeyId: "vip", Time: monday}); err != nil {
+			t.Fatalf("request %d error: %v", i, err)
+		}
+	}
+	if err := engine.Authorize(Request{KeyId: "vip", Time: monday}); !errors.Is(err, ErrDenied) {
+		t.Errorf("request over the key cap error = %v, want %v", err, ErrDenied)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	engine := newEngine(t, Config{Default: Rule{Windows: []Window{
+		{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "18:00"},
+		{Days: []string{"fri"}, Start: "22:00", End: "02:00"},
+	}}})
+
+	tests := []struct {
+		name    string
+		time    time.Time
+		allowed bool
+	}{
+		{"monday morning", monday, true},
+		{"start is inclusive", time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC), true},
+		{"end is exclusive", time.Date(2024, time.January, 1, 18, 0, 0, 0, time.UTC), false},
+		{"monday night", time.Date(2024, time.January, 1, 23, 0, 0, 0, time.UTC), false},
+		{"saturday", time.Date(2024, time.January, 6, 10, 0, 0, 0, time.UTC), false},
+		{"friday night", time.Date(2024, time.January, 5, 23, 0, 0, 0, time.UTC), true},
+		{"after midnight of friday", time.Date(2024, time.January, 6, 1, 0, 0, 0, time.UTC), true},
+		{"after midnight of thursday", time.Date(2024, time.January, 5, 1, 0, 0, 0, time.UTC), false},
+	}
+	for _, test := range tests {
+		err := engine.Authorize(Request{KeyId: "k", Time: test.time})
+		if test.allowed && err != nil {
+			t.Errorf("%s: error: %v", test.name, err)
+		}
+		if !test.allowed && !errors.Is(err, ErrDenied) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, ErrDenied)
+		}
+	}
+}
+
+func TestDeniedMessageHashes(t *testing.T) {
+	engine := newEngine(t, Config{DeniedMessageHashes: []string{" ABCD "}})
+	if err := engine.Authorize(Request{KeyId: "k", MessageHash: []byte{0xab, 0xcd}, Time: monday}); !errors.Is(err, ErrDenied) {
+		t.Errorf("deny-listed hash error = %v, want %v", err, ErrDenied)
+	}
+	if err := engine.Authorize(Request{KeyId: "k", MessageHash: []byte{0xab, 0xce}, Time: monday}); err != nil {
+		t.Errorf("other hash error: %v", err)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	engine := newEngine(t, Config{Default: Rule{RatePerMinute: 1, DailyCap: 1}})
+
+	request := Request{KeyId: "k", Time: monday}
+	if err := engine.Authorize(request); err != nil {
+		t.Fatal(err)
+	}
+	engine.Refund(request)
+	if _, ok := engine.usage["k"]; ok {
+		t.Error("usage is kept after the only request was refunded")
+	}
+	if err := engine.Authorize(Request{KeyId: "k", Time: monday.Add(time.Second)}); err != nil {
+		t.Errorf("request after refund error: %v", err)
+	}
+
+	// 날짜가 바뀐 뒤의 refund 는 새 날의 count 를 줄이지 않습니다.
+	engine.Refund(Request{KeyId: "unknown", Time: monday})
+	engine.Refund(Request{KeyId: "k", Time: monday.Add(-24 * time.Hour)})
+	if err := engine.Authorize(Request{KeyId: "k", Time: monday.Add(2 * time.Minute)}); !errors.Is(err, ErrDenied) {
+		t.Errorf("request over the cap error = %v, want %v", err, ErrDenied)
+	}
+}
+
+func TestReloadKeepsUsage(t *testing.T) {
+	engine := newEngine(t, Config{Default: Rule{DailyCap: 2}})
+	engine.Authorize(Request{KeyId: "k", Time: monday})
+
+	if err := engine.Reload(&Config{Default: Rule{DailyCap: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Authorize(Request{KeyId: "k", Time: monday}); !errors.Is(err, ErrDenied) {
+		t.Errorf("request over the reloaded cap error = %v, want %v", err, ErrDenied)
+	}
+}
+
+func TestLoadFileRejectsInvalidPolicies(t *testing.T) {
+	tests := map[string]string{
+		"invalid json":     `{`,
+		"unknown timezone": `{"timezone": "Mars/Olympus"}`,
+		"negative cap":     `{"default": {"dailyCap": -1}}`,
+		"invalid day":      `{"default": {"windows": [{"days": ["someday"], "start": "09:00", "end": "18:00"}]}}`,
+		"invalid time":     `{"keys": {"k": {"windows": [{"start": "9am", "end": "18:00"}]}}}`,
+		"hash not hex":     `{"deniedMessageHashes": ["xyz"]}`,
+	}
+	for name, content := range tests {
+		path := filepath.Join(t.TempDir(), "policy.json")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
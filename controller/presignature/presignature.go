@@ -0,0 +1,89 @@
+package presignature
+
+import "sync"
+
+// Inventory keeps the presignature IDs generated on this node per key and which of them are consumed.
+// It lives in memory, so IDs generated before a restart are not counted.
+type Inventory struct {
+	mu   sync.RWMutex
+	keys map[string]*keyPresignatures
+}
+
+// MAX_CONSUMED_IDS is the number of the most recently consumed IDs kept per key.
+// Older consumed IDs are only counted so long-lived keys don't grow the inventory without bound.
+const MAX_CONSUMED_IDS int = 100
+
+type keyPresignatures struct {
+	available     []string
+	consumed      []string
+	consumedCount int
+}
+
+type Summary struct {
+	KeyId          string   `json:"keyId" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	AvailableCount int      `json:"availableCount" example:"2"`
+	ConsumedCount  int      `json:"consumedCount" example:"1"`
+	AvailableIds   []string `json:"availableIds"`
+	ConsumedIds    []string `json:"consumedIds"` // the most recently consumed IDs, up to MAX_CONSUMED_IDS
+}
+
+func NewInventory() *Inventory {
+	return &Inventory{keys: make(map[string]*keyPresignatures)}
+}
+
+func (i *Inventory) Add(keyId string, presignatureIds []string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	presignatures := i.getOrCreate(keyId)
+	presignatures.available = append(presignatures.available, presignatureIds...)
+}
+
+func (i *Inventory) Consume(keyId string, presignatureId string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	presignatures := i.getOrCreate(keyId)
+	for idx, id := range presignatures.available {
+		if id == presignatureId {
+			presignatures.available = append(presignatures.available[:idx], presignatures.available[idx+1:]...)
+			break
+		}
+	}
+	presignatures.consumedCount++
+	presignatures.consumed = append(presignatures.consumed, presignatureId)
+	if len(presignatures.consumed) > MAX_CONSUMED_IDS {
+		presignatures.consumed = append([]string(nil), presignatures.consumed[len(presignatures.consumed)-MAX_CONSUMED_IDS:]...)
+	}
+}
+
+func (i *Inventory) Remove(keyId string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.keys, keyId)
+}
+
+func (i *Inventory) Get(keyId string) Summary {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	summary := Summary{KeyId: keyId, AvailableIds: []string{}, ConsumedIds: []string{}}
+	if presignatures, ok := i.keys[keyId]; ok {
+		summary.AvailableIds = append(summary.AvailableIds, presignatures.available...)
+		summary.ConsumedIds = append(summary.ConsumedIds, presignatures.consumed...)
+		summary.ConsumedCount = presignatures.consumedCount
+	}
+	summary.AvailableCount = len(summary.AvailableIds)
+	return summary
+}
+
+func (i *Inventory) getOrCreate(keyId string) *keyPresignatures {
+	// caller must hold i.mu
+	presignatures, ok := i.keys[keyId]
+	if !ok {
+		presignatures = &keyPresignatures{}
+		i.keys[keyId] = presignatures
+	}
+	return presignatures
+}
@@ -0,0 +1,59 @@
+package presignature
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestInventory(t *testing.T) {
+	inventory := NewInventory()
+	inventory.Add("k", []string{"p1", "p2"})
+	inventory.Add("k", []string{"p3"})
+	inventory.Consume("k", "p2")
+	// 다른 node 에서 만들었거나 restart 전에 만든 presignature 도 consumed 로 기록합니다.
+	inventory.Consume("k", "unknown")
+
+	tests := []struct {
+		keyId string
+		want  Summary
+	}{
+		{"k", Summary{KeyId: "k", AvailableCount: 2, ConsumedCount: 2, AvailableIds: []string{"p1", "p3"}, ConsumedIds: []string{"p2", "unknown"}}},
+		{"other", Summary{KeyId: "other", AvailableIds: []string{}, ConsumedIds: []string{}}},
+	}
+	for _, test := range tests {
+		if got := inventory.Get(test.keyId); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Get(%s) = %+v, want %+v", test.keyId, got, test.want)
+		}
+	}
+
+	inventory.Remove("k")
+	if got := inventory.Get("k"); got.AvailableCount != 0 || got.ConsumedCount != 0 {
+		t.Errorf("Get after Remove = %+v", got)
+	}
+}
+
+func TestInventoryKeepsRecentConsumedIds(t *testing.T) {
+	inventory := NewInventory()
+	for i := 0; i < MAX_CONSUMED_IDS+5; i++ {
+		inventory.Consume("k", fmt.Sprintf("p%d", i))
+	}
+
+	summary := inventory.Get("k")
+	if summary.ConsumedCount != MAX_CONSUMED_IDS+5 {
+		t.Errorf("ConsumedCount = %d, want %d", summary.ConsumedCount, MAX_CONSUMED_IDS+5)
+	}
+	if len(summary.ConsumedIds) != MAX_CONSUMED_IDS || summary.ConsumedIds[0] != "p5" || summary.ConsumedIds[MAX_CONSUMED_IDS-1] != fmt.Sprintf("p%d", MAX_CONSUMED_IDS+4) {
+		t.Errorf("ConsumedIds = %d ids from %s, want the last %d", len(summary.ConsumedIds), summary.ConsumedIds[0], MAX_CONSUMED_IDS)
+	}
+}
+
+func TestGetReturnsCopies(t *testing.T) {
+	inventory := NewInventory()
+	inventory.Add("k", []string{"p1"})
+
+	inventory.Get("k").AvailableIds[0] = "changed"
+	if got := inventory.Get("k"); got.AvailableIds[0] != "p1" {
+		t.Error("stored IDs were changed through a returned summary")
+	}
+}
@@ -1,11 +1,24 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 type ErrorString string
 
 const (
-	INVALID_INPUT string = "INVALID_INPUT"
+	INVALID_INPUT     string = "INVALID_INPUT"
+	SESSION_NOT_FOUND string = "SESSION_NOT_FOUND"
+	WRONG_ROLE        string = "WRONG_ROLE"
+	NODE_UNAVAILABLE  string = "NODE_UNAVAILABLE"
+	NODE_AUTH_FAILED  string = "NODE_AUTH_FAILED"
+	KEY_NOT_FOUND     string = "KEY_NOT_FOUND"
+	SESSION_FAILED    string = "SESSION_FAILED"
+	SESSION_TIMEOUT   string = "SESSION_TIMEOUT"
+	SESSION_FINISHED  string = "SESSION_FINISHED"
+	AUDIT_FAILED      string = "AUDIT_FAILED"
+	POLICY_DENIED     string = "POLICY_DENIED"
 )
 
 var (
@@ -27,3 +40,74 @@ func InvalidInputError(err error) *SvcErr {
 		Msg:  err.Error(),
 	}
 }
+
+func SessionNotFoundError(sessionId string) *SvcErr {
+	return &SvcErr{
+		Text: SESSION_NOT_FOUND,
+		Msg:  fmt.Sprintf("session not found: %s", sessionId),
+	}
+}
+
+func WrongRoleError(err error) *SvcErr {
+	return &SvcErr{
+		Text: WRONG_ROLE,
+		Msg:  err.Error(),
+	}
+}
+
+func NodeUnavailableError(err error) *SvcErr {
+	return &SvcErr{
+		Text: NODE_UNAVAILABLE,
+		Msg:  err.Error(),
+	}
+}
+
+// NodeAuthFailedError means the node rejected NODE_API_KEY. It is a configuration error, so it is not reported as NODE_UNAVAILABLE.
+func NodeAuthFailedError(err error) *SvcErr {
+	return &SvcErr{
+		Text: NODE_AUTH_FAILED,
+		Msg:  err.Error(),
+	}
+}
+
+func KeyNotFoundError(err error) *SvcErr {
+	return &SvcErr{
+		Text: KEY_NOT_FOUND,
+		Msg:  err.Error(),
+	}
+}
+
+func SessionFailedError(err error) *SvcErr {
+	return &SvcErr{
+		Text: SESSION_FAILED,
+		Msg:  err.Error(),
+	}
+}
+
+func SessionTimeoutError(sessionId string) *SvcErr {
+	return &SvcErr{
+		Text: SESSION_TIMEOUT,
+		Msg:  fmt.Sprintf("session timed out: %s", sessionId),
+	}
+}
+
+func SessionFinishedError(sessionId string, status string) *SvcErr {
+	return &SvcErr{
+		Text: SESSION_FINISHED,
+		Msg:  fmt.Sprintf("session already finished: %s, status: %s", sessionId, status),
+	}
+}
+
+func AuditFailedError(err error) *SvcErr {
+	return &SvcErr{
+		Text: AUDIT_FAILED,
+		Msg:  fmt.Sprintf("failed to write audit log: %s", err),
+	}
+}
+
+func PolicyDeniedError(err error) *SvcErr {
+	return &SvcErr{
+		Text: POLICY_DENIED,
+		Msg:  err.Error(),
+	}
+}
@@ -3,30 +3,234 @@ package service
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"sort"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
 
+	"github.com/ahnlabio/tsm-controller/audit"
+	"github.com/ahnlabio/tsm-controller/backup"
+	"github.com/ahnlabio/tsm-controller/callback"
 	"github.com/ahnlabio/tsm-controller/config"
+	"github.com/ahnlabio/tsm-controller/metrics"
+	"github.com/ahnlabio/tsm-controller/policy"
+	"github.com/ahnlabio/tsm-controller/presignature"
+	"github.com/ahnlabio/tsm-controller/session"
+	"github.com/ahnlabio/tsm-controller/tsmclient"
 	"github.com/ahnlabio/tsm-controller/tsmutils"
 )
 
 type TSMService struct {
-	config *config.Config
+	config        *config.Config
+	sessions      *session.Registry
+	presignatures *presignature.Inventory
+	keyPolicy     []tsmutils.KeySpec
+	clients       *tsmclient.Manager
+	timeouts      sessionTimeouts
+	callbacks     *callback.Notifier
+	audit         audit.Logger
+	policy        policy.Policy
+	metrics       *metrics.Metrics
+	// operator 가 허용한 backup 수신 RSA key 의 fingerprint. 비어 있으면 backup 을 export 할 수 없습니다.
+	backupRecipients []string
+	// ERS recovery data 를 암호화할 수 있는 RSA key 의 fingerprint. 비어 있으면 recovery data 를 만들 수 없습니다.
+	ersRecipients []string
 }
 
+// sessionTimeouts 는 background MPC session 이 끝나야 하는 시간입니다.
+// mobile player 가 참여하지 않으면 이 시간이 지난 다음 session 이 실패합니다.
+// ers 는 요청 안에서 실행하는 ERS session 이 다른 server player 를 기다리는 시간입니다.
+type sessionTimeouts struct {
+	keygen  time.Duration
+	copyKey time.Duration
+	presign time.Duration
+	reshare time.Duration
+	ers     time.Duration
+}
+
+const DEFAULT_SESSION_TIMEOUT = 2 * time.Minute
+
+const DEFAULT_AUDIT_LOG_FILE = "audit.jsonl"
+
 func NewTSMService(config *config.Config) *TSMService {
-	return &TSMService{config: config}
+	policy := config.KeyPolicy
+	if policy == "" {
+		policy = tsmutils.DEFAULT_KEY_POLICY
+	}
+	keyPolicy, err := tsmutils.ParseKeyPolicy(policy)
+	if err != nil {
+		log.Fatalf("invalid KEY_POLICY: %v", err)
+	}
+	log.Printf("[Service] key policy: %v", keyPolicy)
+
+	healthCheckInterval := tsmclient.DEFAULT_HEALTH_CHECK_INTERVAL
+	if config.NodeHealthCheck != "" {
+		healthCheckInterval, err = time.ParseDuration(config.NodeHealthCheck)
+		if err != nil {
+			log.Fatalf("invalid NODE_HEALTH_CHECK_INTERVAL: %v", err)
+		}
+	}
+
+	timeouts := sessionTimeouts{
+		keygen:  parseTimeout("KEYGEN_TIMEOUT", config.KeygenTimeout),
+		copyKey: parseTimeout("COPY_KEY_TIMEOUT", config.CopyKeyTimeout),
+		presign: parseTimeout("PRESIGN_TIMEOUT", config.PresignTimeout),
+		reshare: parseTimeout("RESHARE_TIMEOUT", config.ReshareTimeout),
+		ers:     parseTimeout("ERS_TIMEOUT", config.ERSTimeout),
+	}
+	log.Printf("[Service] session timeouts. keygen: %s, copyKey: %s, presign: %s, reshare: %s, ers: %s", timeouts.keygen, timeouts.copyKey, timeouts.presign, timeouts.reshare, timeouts.ers)
+
+	callbacks, err := callback.NewNotifier(config.CallbackUrl, config.CallbackHMACKeyId, config.CallbackHMACSecret)
+	if err != nil {
+		log.Fatalf("invalid callback config: %v", err)
+	}
+
+	auditPath := config.AuditLogFile
+	if auditPath == "" {
+		auditPath = DEFAULT_AUDIT_LOG_FILE
+	}
+	auditSecret, err := audit.ParseSecret(config.AuditHMACSecret)
+	if err != nil {
+		log.Fatalf("invalid audit config: %v", err)
+	}
+	auditLog, err := audit.OpenFileLog(auditPath, auditSecret)
+	if err != nil {
+		log.Fatalf("failed to open audit log %s: %v", auditPath, err)
+	}
+
+	signPolicy, err := loadPolicy(config.PolicyFile)
+	if err != nil {
+		log.Fatalf("failed to load policy: %v", err)
+	}
+
+	backupRecipients := config.BackupRecipientFingerprints()
+	if len(backupRecipients) == 0 {
+		log.Printf("[WARN] BACKUP_RECIPIENT_FINGERPRINTS is empty. share backups are disabled")
+	}
+
+	ersRecipients := config.ERSRecipientFingerprints()
+	if len(ersRecipients) == 0 {
+		log.Printf("[WARN] ERS_RECIPIENT_FINGERPRINTS is empty. ERS recovery data export is disabled")
+	}
+
+	sessions := session.NewRegistry()
+	serviceMetrics := metrics.New(prometheus.DefaultRegisterer, config.PlayerIndex, sessions.InFlight)
+	sessions.OnFinish(observeSession(serviceMetrics))
+
+	return &TSMService{
+		config:           config,
+		sessions:         sessions,
+		presignatures:    presignature.NewInventory(),
+		keyPolicy:        keyPolicy,
+		clients:          tsmclient.NewManager(loadNodeSettings, healthCheckInterval),
+		timeouts:         timeouts,
+		callbacks:        callbacks,
+		audit:            auditLog,
+		policy:           signPolicy,
+		metrics:          serviceMetrics,
+		backupRecipients: backupRecipients,
+		ersRecipients:    ersRecipients,
+	}
+}
+
+func loadPolicy(path string) (policy.Policy, error) {
+	if path == "" {
+		log.Printf("[WARN] POLICY_FILE is empty. partial signatures are not limited")
+		return policy.AllowAll{}, nil
+	}
+	policyConfig, err := policy.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Service] loaded policy: %s", path)
+	return policy.NewEngine(policyConfig)
+}
+
+// Ready reports whether the last health check of the node succeeded.
+func (s *TSMService) Ready() bool {
+	return s.clients.Healthy()
+}
+
+// ReloadPolicy reads POLICY_FILE again. Rate limit and daily cap counters are kept.
+func (s *TSMService) ReloadPolicy() error {
+	engine, ok := s.policy.(*policy.Engine)
+	if !ok {
+		return fmt.Errorf("POLICY_FILE is not configured")
+	}
+	policyConfig, err := policy.LoadFile(s.config.PolicyFile)
+	if err != nil {
+		return err
+	}
+	if err := engine.Reload(policyConfig); err != nil {
+		return err
+	}
+	log.Printf("[Service] reloaded policy: %s", s.config.PolicyFile)
+	return nil
+}
+
+func parseTimeout(name string, value string) time.Duration {
+	if value == "" {
+		return DEFAULT_SESSION_TIMEOUT
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		log.Fatalf("invalid %s: %s", name, value)
+	}
+	return timeout
+}
+
+func (s *TSMService) GetSession(sessionId string) (*session.Session, error) {
+	result, ok := s.sessions.Get(sessionId)
+	if !ok {
+		return nil, SessionNotFoundError(sessionId)
+	}
+	return &result, nil
 }
 
-func (s *TSMService) StartGenerateKeySession(sessionId string, publicKey string) error {
+func (s *TSMService) CancelSession(caller string, sessionId string) (*session.Session, error) {
+	/*
+		진행 중인 session 을 취소합니다.
+		MPC 호출의 context 가 취소되므로 node 는 mobile player 를 더 기다리지 않습니다.
+	*/
+	log.Printf("[Service] CancelSession. sessionId: %s, caller: %s", sessionId, caller)
+	if _, ok := s.sessions.Get(sessionId); !ok {
+		return nil, SessionNotFoundError(sessionId)
+	}
+	// 기록되지 않은 취소가 없도록 취소하기 전에 기록합니다. 취소된 결과는 finishSession 이 기록합니다.
+	entry := audit.Entry{SessionId: sessionId, Operation: audit.CANCEL_SESSION, Caller: caller, Outcome: audit.STARTED}
+	if err := s.recordAudit(entry); err != nil {
+		return nil, err
+	}
+
+	result, err := s.sessions.Cancel(sessionId)
+	switch err {
+	case nil:
+		s.finishSession(sessionId)
+		return &result, nil
+	case session.ErrSessionNotFound:
+		return nil, SessionNotFoundError(sessionId)
+	case session.ErrSessionFinished:
+		svcErr := SessionFinishedError(sessionId, result.Status)
+		entry.Outcome = audit.FAILED
+		entry.Error = svcErr.Error()
+		s.recordAudit(entry)
+		return nil, svcErr
+	}
+	return nil, err
+}
+
+func (s *TSMService) StartGenerateKeySession(caller string, sessionId string, publicKey string, algorithm string, curveName string, threshold int) error {
 	/*
 		GenreateKey session 을 시작합니다.
 		Generate Key session 은 모든 노드가 참여합니다.
 		session 시작 요청을 하고난 다음 node0 가 session 에 참여해 key 를 생성합니다.
 	*/
-	log.Printf("[Service] GenerateKey. sessionId: %s, publicKey: %s", sessionId, publicKey)
+	log.Printf("[Service] GenerateKey. sessionId: %s, publicKey: %s, algorithm: %s, curveName: %s, threshold: %d", sessionId, publicKey, algorithm, curveName, threshold)
 	sessionConfig, err := s.createKeygenSessionConfig(sessionId, publicKey)
 	if err != nil {
 		// encoding error. bad request 처리
@@ -34,29 +238,50 @@ func (s *TSMService) StartGenerateKeySession(sessionId string, publicKey string)
 		return err
 	}
 
-	client := s.getClient()
-	threshold := 1 // The security threshold of the key
-	curveName := "ED-25519"
+	keySpec, err := s.resolveKeySpec(algorithm, curveName, threshold)
+	if err != nil {
+		return err
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+	keyAPI, err := getKeyAPI(client, keySpec.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := s.sessions.Create(sessionId, session.GENERATE_KEY, caller, s.timeouts.keygen)
+	if err != nil {
+		return InvalidInputError(err)
+	}
+	if err := s.startAudit(sessionId, session.GENERATE_KEY, caller, ""); err != nil {
+		return err
+	}
 
 	// 아래 go routine 이 실행되고난 다음 node0 또한 session 을 시작해야 합니다.
-	go func() error {
-		ctx := context.Background()
+	go func() {
+		s.sessions.Start(sessionId)
 		log.Printf("GenerateKey session started. playerIndex: %s", s.config.PlayerIndex)
-		log.Printf("client.Schnorr().GenerateKey. curveName: %s", curveName)
-		keyId, err := client.Schnorr().GenerateKey(ctx, sessionConfig, threshold, curveName, "")
+		log.Printf("GenerateKey. keySpec: %s", keySpec)
+		keyId, err := keyAPI.GenerateKey(ctx, sessionConfig, keySpec.Threshold, keySpec.Curve, "")
 		if err != nil {
 			log.Printf("Error generating key: %v", err)
-			return err
+			s.failSession(ctx, sessionId, err)
+			return
 		}
 		log.Printf("Generated key with ID: %s, playerIndex: %s", keyId, s.config.PlayerIndex)
-		return err
+		if s.sessions.Succeed(sessionId, keyId, nil) {
+			s.finishSession(sessionId)
+		}
 	}()
 
 	return nil
 }
 
-func (s *TSMService) StartCopyKeySession(sessionId string, publicKey string, existingKeyId string) error {
-	log.Printf("[Service] CopyKey. sessionId: %s, publicKey: %s, existingKeyID: %s", sessionId, publicKey, existingKeyId)
+func (s *TSMService) StartCopyKeySession(caller string, sessionId string, publicKey string, existingKeyId string, algorithm string, curveName string, newThreshold int) error {
+	log.Printf("[Service] CopyKey. sessionId: %s, publicKey: %s, existingKeyID: %s, algorithm: %s, curveName: %s, newThreshold: %d", sessionId, publicKey, existingKeyId, algorithm, curveName, newThreshold)
 	sessionConfig, err := s.createKeygenSessionConfig(sessionId, publicKey)
 	if err != nil {
 		// encoding error. bad request 처리
@@ -64,69 +289,592 @@ func (s *TSMService) StartCopyKeySession(sessionId string, publicKey string, exi
 		return err
 	}
 
-	client := s.getClient()
-	newThreshold := 1 // The security threshold of the key
-	curveName := "ED-25519"
+	keySpec, err := s.resolveKeySpec(algorithm, curveName, newThreshold)
+	if err != nil {
+		return err
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+	keyAPI, err := getKeyAPI(client, keySpec.Algorithm)
+	if err != nil {
+		return err
+	}
 
-	go func() error {
-		var err error
-		ctx := context.Background()
-		log.Printf("client.Schnorr().CopyKey(). curveName: %s", curveName)
-		newKeyId, err := client.Schnorr().CopyKey(ctx, sessionConfig, existingKeyId, curveName, newThreshold, "")
+	ctx, err := s.sessions.Create(sessionId, session.COPY_KEY, caller, s.timeouts.copyKey)
+	if err != nil {
+		return InvalidInputError(err)
+	}
+	if err := s.startAudit(sessionId, session.COPY_KEY, caller, existingKeyId); err != nil {
+		return err
+	}
+
+	go func() {
+		s.sessions.Start(sessionId)
+		log.Printf("CopyKey. keySpec: %s", keySpec)
+		newKeyId, err := keyAPI.CopyKey(ctx, sessionConfig, existingKeyId, keySpec.Curve, keySpec.Threshold, "")
 		if err != nil {
 			log.Printf("Error generating key: %v", err)
-			return err
+			s.failSession(ctx, sessionId, err)
+			return
 		}
 		log.Printf("Copied existingKeyID: %s, newKeyId: %s, playerIndex: %s", existingKeyId, newKeyId, s.config.PlayerIndex)
+		if s.sessions.Succeed(sessionId, newKeyId, nil) {
+			s.finishSession(sessionId)
+		}
+	}()
+
+	return nil
+}
+
+func (s *TSMService) StartImportKeySession(caller string, sessionId string, publicKey string, algorithm string, threshold int, wrappedKeyShare string, wrappedChainCode string, pkixPublicKey string) error {
+	/*
+		mobile client 가 secret sharing 한 기존 private key 를 가져옵니다.
+		각 share 는 받는 player 의 wrapping key 로 암호화되어 있으므로 appserver 는 share 를 알 수 없습니다.
+		share 로 만든 key 가 pkixPublicKey 와 다르면 session 이 실패하므로 가져온 key 의 public key 는 기존 wallet 과 같습니다.
+	*/
+	log.Printf("[Service] ImportKey. sessionId: %s, publicKey: %s, algorithm: %s, threshold: %d, pkixPublicKey: %s", sessionId, publicKey, algorithm, threshold, pkixPublicKey)
+	sessionConfig, err := s.createKeygenSessionConfig(sessionId, publicKey)
+	if err != nil {
+		log.Printf("ImportKey Service Error creating session config: %v", err)
+		return err
+	}
+
+	wrappedKeyShareBytes, err := base64.StdEncoding.DecodeString(wrappedKeyShare)
+	if err != nil {
+		return InvalidInputError(fmt.Errorf("wrappedKeyShare is not base64: %w", err))
+	}
+	// chain code 가 없으면 node 가 임의의 chain code 를 만듭니다.
+	var wrappedChainCodeBytes []byte
+	if wrappedChainCode != "" {
+		wrappedChainCodeBytes, err = base64.StdEncoding.DecodeString(wrappedChainCode)
+		if err != nil {
+			return InvalidInputError(fmt.Errorf("wrappedChainCode is not base64: %w", err))
+		}
+	}
+	pkixPublicKeyBytes, err := base64.StdEncoding.DecodeString(pkixPublicKey)
+	if err != nil {
+		return InvalidInputError(fmt.Errorf("pkixPublicKey is not base64: %w", err))
+	}
+
+	// curve 는 가져오는 key 에서 정해지므로 요청 값 대신 public key 의 curve 로 KEY_POLICY 를 확인합니다.
+	curveName, err := tsmutils.PKIXCurve(pkixPublicKeyBytes)
+	if err != nil {
+		return errHandler(err)
+	}
+	keySpec, err := s.resolveKeySpec(algorithm, curveName, threshold)
+	if err != nil {
 		return err
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+	keyAPI, err := getKeyAPI(client, keySpec.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	// import 는 key 생성과 같은 ceremony 이므로 KEYGEN_TIMEOUT 을 사용합니다.
+	ctx, err := s.sessions.Create(sessionId, session.IMPORT_KEY, caller, s.timeouts.keygen)
+	if err != nil {
+		return InvalidInputError(err)
+	}
+	if err := s.startAudit(sessionId, session.IMPORT_KEY, caller, ""); err != nil {
+		return err
+	}
+
+	go func() {
+		s.sessions.Start(sessionId)
+		log.Printf("ImportKeyShares. keySpec: %s", keySpec)
+		keyId, err := keyAPI.ImportKeyShares(ctx, sessionConfig, keySpec.Threshold, wrappedKeyShareBytes, wrappedChainCodeBytes, pkixPublicKeyBytes, "")
+		if err != nil {
+			log.Printf("Error importing key: %v", err)
+			s.failSession(ctx, sessionId, err)
+			return
+		}
+		log.Printf("Imported key with ID: %s, playerIndex: %s", keyId, s.config.PlayerIndex)
+		if s.sessions.Succeed(sessionId, keyId, nil) {
+			s.finishSession(sessionId)
+		}
 	}()
 
 	return nil
 }
 
-func (s *TSMService) StartPresignSession(sessionId string, publicKey string, keyId string, presignatureCount uint64) error {
-	log.Printf("[Service] PreSign. sessionId: %s, publicKey: %s, keyId: %s, presignatureCount: %d", sessionId, publicKey, keyId, presignatureCount)
+// WrappingKey returns the DER SubjectPublicKeyInfo RSA key this node's key share must be wrapped with for import.
+func (s *TSMService) WrappingKey(ctx context.Context) ([]byte, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+	wrappingKey, err := client.WrappingKey().WrappingKey(ctx)
+	if err != nil {
+		return nil, errHandler(err)
+	}
+	return wrappingKey, nil
+}
+
+func (s *TSMService) StartReshareSession(caller string, sessionId string, publicKey string, keyId string, algorithm string) error {
+	/*
+		key 의 secret sharing 을 새로 만듭니다. public key 와 keyId 는 바뀌지 않습니다.
+		mobile player 를 포함한 모든 player 가 참여해야 하며, 끝나면 이전 share (backup 포함) 와 presignature 는 쓸 수 없습니다.
+	*/
+	log.Printf("[Service] Reshare. sessionId: %s, publicKey: %s, keyId: %s, algorithm: %s", sessionId, publicKey, keyId, algorithm)
+	sessionConfig, err := s.createKeygenSessionConfig(sessionId, publicKey)
+	if err != nil {
+		log.Printf("Reshare Service Error creating session config: %v", err)
+		return err
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+	keyAPI, err := getKeyAPI(client, algorithm)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := s.sessions.Create(sessionId, session.RESHARE, caller, s.timeouts.reshare)
+	if err != nil {
+		return InvalidInputError(err)
+	}
+	if err := s.startAudit(sessionId, session.RESHARE, caller, keyId); err != nil {
+		return err
+	}
+
+	go func() {
+		s.sessions.Start(sessionId)
+		log.Printf("Reshare. algorithm: %s", algorithm)
+		if err := keyAPI.Reshare(ctx, sessionConfig, keyId); err != nil {
+			// 실패하면 성공할 때까지 다시 시도해야 합니다. 그 전까지는 이 key 를 사용하는 다른 요청이 실패할 수 있습니다.
+			log.Printf("Error resharing key: %v", err)
+			s.failSession(ctx, sessionId, err)
+			return
+		}
+
+		// node 가 presignature 를 삭제하므로 inventory 에서도 삭제합니다.
+		s.presignatures.Remove(keyId)
+		log.Printf("Reshared keyId: %s, playerIndex: %s", keyId, s.config.PlayerIndex)
+		if s.sessions.Succeed(sessionId, keyId, nil) {
+			s.finishSession(sessionId)
+		}
+	}()
+
+	return nil
+}
+
+func (s *TSMService) StartPresignSession(caller string, sessionId string, publicKey string, keyId string, presignatureCount uint64, algorithm string) error {
+	log.Printf("[Service] PreSign. sessionId: %s, publicKey: %s, keyId: %s, presignatureCount: %d, algorithm: %s", sessionId, publicKey, keyId, presignatureCount, algorithm)
 	sessionConfig, err := s.createSignSessionConfig(sessionId, publicKey)
 	if err != nil {
-		log.Printf("GenerateKey Service Error creating session config: %v", err)
-		return nil
+		log.Printf("PreSign Service Error creating session config: %v", err)
+		return err
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+	keyAPI, err := getKeyAPI(client, algorithm)
+	if err != nil {
+		return err
 	}
 
-	client := s.getClient()
-	go func() error {
-		var err error
-		ctx := context.Background()
-		log.Printf("client.Schnorr().GeneratePresignatures()")
-		_, err = client.Schnorr().GeneratePresignatures(ctx, sessionConfig, keyId, presignatureCount)
+	ctx, err := s.sessions.Create(sessionId, session.PRESIGN, caller, s.timeouts.presign)
+	if err != nil {
+		return InvalidInputError(err)
+	}
+	if err := s.startAudit(sessionId, session.PRESIGN, caller, keyId); err != nil {
+		return err
+	}
+
+	go func() {
+		s.sessions.Start(sessionId)
+		log.Printf("GeneratePresignatures. algorithm: %s", algorithm)
+		presignatureIds, err := keyAPI.GeneratePresignatures(ctx, sessionConfig, keyId, presignatureCount)
 		if err != nil {
 			log.Printf("Error generating presignature: %v", err)
+			s.failSession(ctx, sessionId, err)
+			return
 		}
 
 		log.Printf("Generated presignature. playerIndex: %s", s.config.PlayerIndex)
-		return err
+		s.presignatures.Add(keyId, presignatureIds)
+		if s.sessions.Succeed(sessionId, keyId, presignatureIds) {
+			s.finishSession(sessionId)
+		}
 	}()
 
 	return nil
 }
 
-func (s *TSMService) PartialSign(preSignatureId string, messageHash string, keyId string) (string, error) {
-	log.Printf("[Service] PartialSign. preSignatureId: %s, messageHash: %s, keyId: %s", preSignatureId, messageHash, keyId)
+func (s *TSMService) PartialSign(ctx context.Context, caller string, preSignatureId string, mode string, messageHash string, message string, keyId string, algorithm string, derivationPath []uint32) (string, error) {
+	requestedAt := time.Now()
+	partialSignature, err := s.partialSign(ctx, preSignatureId, mode, messageHash, message, keyId, algorithm, derivationPath)
+	s.metrics.Observe(metrics.PARTIAL_SIGN, partialSignOutcome(err), time.Since(requestedAt))
 
-	client := s.getClient()
-	messageHashBytes, err := base64.StdEncoding.DecodeString(messageHash)
+	signed := messageHash
+	if mode == tsmutils.SIGN_MODE_MESSAGE {
+		signed = message
+	}
+	entry := audit.Entry{KeyId: keyId, Operation: audit.PARTIAL_SIGN, Caller: caller, MessageHashDigest: messageHashDigest(signed), Outcome: audit.SUCCEEDED}
 	if err != nil {
+		entry.Outcome = audit.FAILED
+		entry.Error = err.Error()
+		s.recordAudit(entry)
 		return "", err
 	}
+	// 기록되지 않은 서명이 나가지 않도록 audit log 에 쓰지 못하면 partial signature 를 반환하지 않습니다.
+	if err := s.recordAudit(entry); err != nil {
+		return "", err
+	}
+	return partialSignature, nil
+}
+
+const MAX_PARTIAL_SIGN_BATCH_SIZE int = 100
+
+type PartialSignItem struct {
+	PreSignatureId string
+	Mode           string
+	MessageHash    string
+	Message        string
+	KeyId          string
+	Algorithm      string
+	DerivationPath []uint32
+}
+
+type PartialSignItemResult struct {
+	Signature string
+	Err       error
+}
+
+// PartialSignBatch signs every item of a multi-instruction bundle in one call.
+// Items are independent: a failed item does not stop or roll back the others, and each
+// item is checked by the policy and written to the audit log as a single PartialSign.
+// Only an invalid batch (empty or too large) fails as a whole.
+// If ctx is cancelled because the caller went away, the remaining items fail and are not signed.
+func (s *TSMService) PartialSignBatch(ctx context.Context, caller string, items []PartialSignItem) ([]PartialSignItemResult, error) {
+	log.Printf("[Service] PartialSignBatch. count: %d", len(items))
+
+	if len(items) == 0 || len(items) > MAX_PARTIAL_SIGN_BATCH_SIZE {
+		return nil, InvalidInputError(fmt.Errorf("batch size must be between 1 and %d: %d", MAX_PARTIAL_SIGN_BATCH_SIZE, len(items)))
+	}
+
+	// policy 의 rate limit 이 요청 순서대로 적용되도록 순서대로 서명합니다.
+	results := make([]PartialSignItemResult, len(items))
+	for i, item := range items {
+		signature, err := s.PartialSign(ctx, caller, item.PreSignatureId, item.Mode, item.MessageHash, item.Message, item.KeyId, item.Algorithm, item.DerivationPath)
+		results[i] = PartialSignItemResult{Signature: signature, Err: err}
+	}
+	return results, nil
+}
 
-	log.Printf("client.Schnorr().SignWithPresignature()")
-	partialSignResult, err := client.Schnorr().SignWithPresignature(context.TODO(), keyId, preSignatureId, nil, messageHashBytes[:])
+func (s *TSMService) partialSign(ctx context.Context, preSignatureId string, mode string, messageHash string, message string, keyId string, algorithm string, derivationPath []uint32) (string, error) {
+	log.Printf("[Service] PartialSign. preSignatureId: %s, mode: %s, messageHash: %s, keyId: %s, algorithm: %s, derivationPath: %v", preSignatureId, mode, messageHash, keyId, algorithm, derivationPath)
+
+	if err := tsmutils.ValidateDerivationPath(derivationPath); err != nil {
+		return "", errHandler(err)
+	}
+	signData, err := tsmutils.SignData(mode, messageHash, message, algorithm)
+	if err != nil {
+		return "", errHandler(err)
+	}
+
+	client, err := s.getClient()
 	if err != nil {
 		return "", err
 	}
+	keyAPI, err := getKeyAPI(client, algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	// 탈취된 appserver 가 지갑을 비울 수 없도록 서명 전에 policy 를 확인합니다.
+	// 서명하지 못한 요청은 rate limit 과 daily cap 에서 다시 뺍니다.
+	policyRequest := policy.Request{KeyId: keyId, MessageHash: tsmutils.PolicyHash(mode, signData), Time: time.Now()}
+	if err := s.policy.Authorize(policyRequest); err != nil {
+		log.Printf("PartialSign denied by policy. keyId: %s, error: %v", keyId, err)
+		return "", PolicyDeniedError(err)
+	}
+
+	log.Printf("SignWithPresignature. algorithm: %s", algorithm)
+	partialSignResult, err := keyAPI.SignWithPresignature(ctx, keyId, preSignatureId, derivationPath, signData)
+	if err != nil {
+		s.policy.Refund(policyRequest)
+		return "", errHandler(err)
+	}
+
+	// presignatureId 가 비어 있으면 node 가 임의의 presignature 를 사용하므로 결과의 ID 를 기록합니다.
+	s.presignatures.Consume(keyId, partialSignResult.PresignatureID)
 	partialSignature := base64.StdEncoding.EncodeToString(partialSignResult.PartialSignature)
 	return partialSignature, nil
 }
 
+// PublicKeyResult is the public key of a key and the algorithm it was looked up with.
+type PublicKeyResult struct {
+	Algorithm string
+	*tsmutils.PublicKeyInfo
+}
+
+func (s *TSMService) PublicKey(ctx context.Context, keyId string, algorithm string, derivationPath string) (*PublicKeyResult, error) {
+	log.Printf("[Service] PublicKey. keyId: %s, algorithm: %s, derivationPath: %s", keyId, algorithm, derivationPath)
+	// 응답에 실제로 사용한 algorithm 을 알려 주도록 기본값을 채웁니다.
+	if algorithm == "" {
+		algorithm = tsmutils.SCHNORR
+	}
+
+	path, err := tsmutils.ParseDerivationPath(derivationPath)
+	if err != nil {
+		return nil, errHandler(err)
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+	keyAPI, err := getKeyAPI(client, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	pkixPublicKey, err := keyAPI.PublicKey(ctx, keyId, path)
+	if err != nil {
+		return nil, errHandler(err)
+	}
+
+	publicKeyInfo, err := tsmutils.GetPublicKeyInfo(pkixPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKeyResult{Algorithm: algorithm, PublicKeyInfo: publicKeyInfo}, nil
+}
+
+func (s *TSMService) DeleteKey(ctx context.Context, caller string, keyId string) error {
+	// 기록되지 않은 삭제가 없도록 audit log 에 쓰지 못하면 삭제하지 않습니다.
+	entry := audit.Entry{KeyId: keyId, Operation: audit.DELETE_KEY, Caller: caller, Outcome: audit.STARTED}
+	if err := s.recordAudit(entry); err != nil {
+		return err
+	}
+
+	err := s.deleteKey(ctx, keyId)
+
+	entry.Outcome = audit.SUCCEEDED
+	if err != nil {
+		entry.Outcome = audit.FAILED
+		entry.Error = err.Error()
+		s.recordAudit(entry)
+		return err
+	}
+	if err := s.recordAudit(entry); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ExportAudit writes the whole audit log for compliance reviews.
+func (s *TSMService) ExportAudit(w io.Writer) error {
+	return s.audit.Export(w)
+}
+
+// BackupKeyShare exports this node's share of a key encrypted to an operator RSA key.
+// The recipient must be one of BACKUP_RECIPIENT_FINGERPRINTS so a compromised appserver can't export shares to its own key.
+func (s *TSMService) BackupKeyShare(ctx context.Context, caller string, keyId string, algorithm string, recipientPublicKey string) (*backup.Envelope, error) {
+	envelope, err := s.backupKeyShare(ctx, keyId, algorithm, recipientPublicKey)
+
+	entry := audit.Entry{KeyId: keyId, Operation: audit.BACKUP_SHARE, Caller: caller, Outcome: audit.SUCCEEDED}
+	if err != nil {
+		entry.Outcome = audit.FAILED
+		entry.Error = err.Error()
+		s.recordAudit(entry)
+		return nil, err
+	}
+	// 기록되지 않은 backup 이 나가지 않도록 audit log 에 쓰지 못하면 backup 을 반환하지 않습니다.
+	if err := s.recordAudit(entry); err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
+
+func (s *TSMService) backupKeyShare(ctx context.Context, keyId string, algorithm string, recipientPublicKey string) (*backup.Envelope, error) {
+	log.Printf("[Service] BackupKeyShare. keyId: %s, algorithm: %s, playerIndex: %s", keyId, algorithm, s.config.PlayerIndex)
+
+	recipient, err := backup.ParseRecipient(recipientPublicKey)
+	if err != nil {
+		return nil, InvalidInputError(err)
+	}
+	if !recipient.Allowed(s.backupRecipients) {
+		return nil, PolicyDeniedError(fmt.Errorf("%w: %s", backup.ErrRecipientNotAllowed, recipient.KeyId))
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+	keyAPI, err := getKeyAPI(client, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	// node 설정에서 EnableShareBackup 이 꺼져 있으면 실패합니다.
+	shareBackup, err := keyAPI.BackupKeyShare(ctx, keyId)
+	if err != nil {
+		return nil, errHandler(err)
+	}
+	defer clear(shareBackup)
+
+	envelope, err := backup.Seal(recipient, keyId, s.config.PlayerIndex, shareBackup)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Exported share backup. keyId: %s, playerIndex: %s, recipient: %s", keyId, s.config.PlayerIndex, recipient.KeyId)
+	return envelope, nil
+}
+
+// GenerateRecoveryData runs an ERS session with the other server player and returns this player's partial recovery data.
+// The appserver combines the partial recovery data of player 1 and 2 into the recovery data of the key.
+func (s *TSMService) GenerateRecoveryData(ctx context.Context, caller string, sessionId string, keyId string, algorithm string, ersPublicKey string, ersLabel string) ([]byte, error) {
+	partialRecoveryData, err := s.generateRecoveryData(ctx, sessionId, keyId, algorithm, ersPublicKey, ersLabel)
+
+	entry := audit.Entry{SessionId: sessionId, KeyId: keyId, Operation: audit.RECOVERY_DATA, Caller: caller, Outcome: audit.SUCCEEDED}
+	if err != nil {
+		entry.Outcome = audit.FAILED
+		entry.Error = err.Error()
+		s.recordAudit(entry)
+		return nil, err
+	}
+	if err := s.recordAudit(entry); err != nil {
+		return nil, err
+	}
+	return partialRecoveryData, nil
+}
+
+func (s *TSMService) generateRecoveryData(ctx context.Context, sessionId string, keyId string, algorithm string, ersPublicKey string, ersLabel string) ([]byte, error) {
+	/*
+		ERS recovery data 는 player 1, 2 만 참여하는 session 에서 만듭니다.
+		appserver 는 두 player 에게 같은 sessionId, ERS public key, label 로 동시에 요청해야 합니다.
+	*/
+	log.Printf("[Service] GenerateRecoveryData. sessionId: %s, keyId: %s, algorithm: %s, playerIndex: %s", sessionId, keyId, algorithm, s.config.PlayerIndex)
+
+	recipient, err := backup.ParseRecipient(ersPublicKey)
+	if err != nil {
+		return nil, InvalidInputError(err)
+	}
+	if !recipient.Allowed(s.ersRecipients) {
+		return nil, PolicyDeniedError(fmt.Errorf("%w: %s", backup.ErrRecipientNotAllowed, recipient.KeyId))
+	}
+
+	nodeConfig := tsmutils.NodeConfig{
+		PlayerIndex:          s.config.PlayerIndex,
+		NodePubicKey:         s.config.NodePubicKey,
+		AnotherNodePublicKey: s.config.AnotherNodePublicKey,
+	}
+	sessionConfig, err := tsmutils.CreateServerSessionConfig(sessionId, nodeConfig)
+	if err != nil {
+		return nil, errHandler(err)
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+	keyAPI, err := getKeyAPI(client, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	// 다른 player 가 session 에 참여하지 않으면 ERS_TIMEOUT 이 지나거나 appserver 가 요청을 끊을 때까지만 기다립니다.
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.ers)
+	defer cancel()
+
+	// node 설정에서 EnableERSExport 가 꺼져 있으면 실패합니다.
+	partialRecoveryData, err := keyAPI.GenerateRecoveryData(ctx, sessionConfig, keyId, recipient.PublicKey, []byte(ersLabel))
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, SessionTimeoutError(sessionId)
+		}
+		return nil, errHandler(err)
+	}
+	log.Printf("Generated partial recovery data. keyId: %s, playerIndex: %s, recipient: %s", keyId, s.config.PlayerIndex, recipient.KeyId)
+	return partialRecoveryData, nil
+}
+
+func (s *TSMService) deleteKey(ctx context.Context, keyId string) error {
+	/*
+		이 node 의 key share 를 삭제합니다.
+		다른 node 의 share 는 삭제되지 않으므로 appserver 가 모든 node 에 요청해야 합니다.
+	*/
+	log.Printf("[Service] DeleteKey. keyId: %s, playerIndex: %s", keyId, s.config.PlayerIndex)
+
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+	// key share 를 삭제하면 presignature 는 쓸 수 없으므로 먼저 삭제합니다. 없을 수도 있으므로 실패는 무시합니다.
+	if err := client.KeyManagement().DeletePresignatures(ctx, keyId); err != nil {
+		log.Printf("DeletePresignatures failed. keyId: %s, error: %v", keyId, err)
+	}
+	if err := client.KeyManagement().DeleteKeyShare(ctx, keyId); err != nil {
+		return errHandler(err)
+	}
+
+	s.presignatures.Remove(keyId)
+	log.Printf("Deleted key share. keyId: %s, playerIndex: %s", keyId, s.config.PlayerIndex)
+	return nil
+}
+
+func (s *TSMService) GetPresignatures(keyId string) presignature.Summary {
+	return s.presignatures.Get(keyId)
+}
+
+const (
+	DEFAULT_KEY_LIST_LIMIT int = 100
+	MAX_KEY_LIST_LIMIT     int = 1000
+)
+
+type KeyList struct {
+	KeyIds []string `json:"keyIds"`
+	Total  int      `json:"total" example:"1"`
+	Offset int      `json:"offset" example:"0"`
+	Limit  int      `json:"limit" example:"100"`
+}
+
+func (s *TSMService) ListKeys(ctx context.Context, offset int, limit int) (*KeyList, error) {
+	log.Printf("[Service] ListKeys. offset: %d, limit: %d", offset, limit)
+	if limit == 0 {
+		limit = DEFAULT_KEY_LIST_LIMIT
+	}
+	if offset < 0 || limit < 0 || limit > MAX_KEY_LIST_LIMIT {
+		return nil, InvalidInputError(fmt.Errorf("offset must be >= 0 and limit must be between 1 and %d", MAX_KEY_LIST_LIMIT))
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+	keyIds, err := client.KeyManagement().ListKeys(ctx)
+	if err != nil {
+		return nil, errHandler(err)
+	}
+
+	// node 가 반환하는 순서는 보장되지 않으므로 정렬해서 페이지를 나눕니다.
+	sort.Strings(keyIds)
+	total := len(keyIds)
+	start := min(offset, total)
+	end := min(offset+limit, total)
+
+	return &KeyList{
+		KeyIds: keyIds[start:end],
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+	}, nil
+}
+
 func (s *TSMService) createKeygenSessionConfig(sessionId string, player0PublicKey string) (*tsm.SessionConfig, error) {
 	/*
 		session config 를 생성합니다.
@@ -163,7 +911,7 @@ func (s *TSMService) createSignSessionConfig(sessionId string, player0PublicKey
 		node1,node2 의 public key 는 설정파일에 저장되어 실행 시점부터 정해져 있습니다.
 	*/
 	if s.config.PlayerIndex != "1" {
-		panic(fmt.Errorf("player is not allowed for sign"))
+		return nil, errHandler(tsmutils.WrongRoleError(s.config.PlayerIndex, "sign"))
 	}
 
 	nodeConfig := tsmutils.NodeConfig{
@@ -178,20 +926,194 @@ func (s *TSMService) createSignSessionConfig(sessionId string, player0PublicKey
 	return sessionConfig, nil
 }
 
-func (s *TSMService) getClient() *tsm.Client {
-	tsmConfig := tsm.Configuration{URL: s.config.NodeUrl}.WithAPIKeyAuthentication(s.config.NodeApiKey)
-	return tsmutils.GetClientFromConfig(tsmConfig)
+func (s *TSMService) getClient() (*tsm.Client, error) {
+	client, err := s.clients.Client()
+	if err != nil {
+		return nil, errHandler(err)
+	}
+	return client, nil
+}
+
+func loadNodeSettings() tsmclient.Settings {
+	nodeUrl, nodeApiKey := config.ReadNodeSettings()
+	return tsmclient.Settings{URL: nodeUrl, APIKey: nodeApiKey}
+}
+
+func getKeyAPI(client *tsm.Client, algorithm string) (tsmutils.KeyAPI, error) {
+	keyAPI, err := tsmutils.GetKeyAPI(client, algorithm)
+	if err != nil {
+		return nil, errHandler(err)
+	}
+	return keyAPI, nil
+}
+
+func (s *TSMService) resolveKeySpec(algorithm string, curveName string, threshold int) (tsmutils.KeySpec, error) {
+	/*
+		요청에 없는 값은 기본값으로 채운 다음
+		KEY_POLICY 에 허용된 조합인지 확인합니다.
+	*/
+	if algorithm == "" {
+		algorithm = tsmutils.SCHNORR
+	}
+	if curveName == "" {
+		defaultCurveName, err := tsmutils.DefaultCurve(algorithm)
+		if err != nil {
+			return tsmutils.KeySpec{}, errHandler(err)
+		}
+		curveName = defaultCurveName
+	}
+	if threshold == 0 {
+		threshold = 1
+	}
+
+	keySpec := tsmutils.KeySpec{Algorithm: algorithm, Curve: curveName, Threshold: threshold}
+	for _, allowed := range s.keyPolicy {
+		if allowed == keySpec {
+			return keySpec, nil
+		}
+	}
+	return tsmutils.KeySpec{}, InvalidInputError(fmt.Errorf("key spec %s is not allowed by key policy", keySpec))
+}
+
+// startAudit 는 session 시작을 기록합니다. 기록하지 못하면 session 을 실행하지 않습니다.
+func (s *TSMService) startAudit(sessionId string, operation string, caller string, keyId string) error {
+	err := s.recordAudit(audit.Entry{SessionId: sessionId, KeyId: keyId, Operation: operation, Caller: caller, Outcome: audit.STARTED})
+	if err != nil {
+		s.sessions.Fail(sessionId, AUDIT_FAILED, err)
+		return err
+	}
+	return nil
+}
+
+func (s *TSMService) recordAudit(entry audit.Entry) error {
+	if err := s.audit.Append(entry); err != nil {
+		log.Printf("[Service] failed to write audit log. operation: %s, sessionId: %s, keyId: %s, error: %v", entry.Operation, entry.SessionId, entry.KeyId, err)
+		return AuditFailedError(err)
+	}
+	return nil
+}
+
+// messageHashDigest 는 서명한 message hash (message mode 에서는 message) 를 저장하지 않고 확인할 수 있도록 digest 를 만듭니다.
+func messageHashDigest(messageHash string) string {
+	messageHashBytes, err := base64.StdEncoding.DecodeString(messageHash)
+	if err != nil {
+		messageHashBytes = []byte(messageHash)
+	}
+	return audit.Digest(messageHashBytes)
 }
 
 func errHandler(err error) error {
+	if _, ok := err.(*SvcErr); ok {
+		return err
+	}
+
 	if errorInfo, ok := err.(*tsmutils.TsmUtilsErr); ok {
-		if errorInfo.Text == tsmutils.DECODING_ERROR {
+		switch errorInfo.Text {
+		case tsmutils.DECODING_ERROR, tsmutils.UNSUPPORTED_ALGORITHM, tsmutils.INVALID_DERIVATION_PATH, tsmutils.INVALID_PUBLIC_KEY, tsmutils.INVALID_SIGN_REQUEST:
 			// error 변환
 			return InvalidInputError(err)
+		case tsmutils.WRONG_ROLE:
+			return WrongRoleError(err)
+		case tsmutils.NODE_UNAVAILABLE:
+			return NodeUnavailableError(err)
 		}
 
 		//log.Printf("[ERROR] err: %s, url: %s, status: %d\n", err.Error(), c.Request.URL, status)
+		return err
+	}
+
+	/*
+		TSM SDK 의 error 를 변환합니다.
+		요청자의 잘못(INVALID_INPUT, KEY_NOT_FOUND), node 설정 오류(NODE_AUTH_FAILED)와 node 장애(NODE_UNAVAILABLE)를 구분합니다.
+	*/
+	switch {
+	case tsmutils.IsKeyNotFound(err):
+		return KeyNotFoundError(err)
+	case errors.Is(err, tsm.ErrInvalidInput):
+		return InvalidInputError(err)
+	case errors.Is(err, tsm.ErrAuthentication):
+		return NodeAuthFailedError(err)
+	case tsmutils.IsNodeUnavailable(err):
+		return NodeUnavailableError(err)
+	case errors.Is(err, tsm.ErrOperationFailed):
+		return SessionFailedError(err)
 	}
 
 	return err
 }
+
+// observeSession 은 session 이 끝나면 요청부터 SDK 호출이 끝날 때까지의 시간을 기록합니다.
+func observeSession(serviceMetrics *metrics.Metrics) func(session.Session) {
+	return func(result session.Session) {
+		outcome := result.Status
+		if result.ErrorText == SESSION_TIMEOUT {
+			outcome = metrics.TIMEOUT
+		}
+		serviceMetrics.Observe(result.Operation, outcome, result.FinishedAt.Sub(result.CreatedAt))
+	}
+}
+
+func partialSignOutcome(err error) string {
+	if err == nil {
+		return metrics.SUCCEEDED
+	}
+	if svcErr, ok := err.(*SvcErr); ok && svcErr.Text == POLICY_DENIED {
+		return metrics.DENIED
+	}
+	return metrics.FAILED
+}
+
+// failSession 은 background session 의 error 를 분류해서 기록합니다.
+func (s *TSMService) failSession(ctx context.Context, sessionId string, err error) {
+	var svcErr *SvcErr
+	switch ctx.Err() {
+	case context.Canceled:
+		// CancelSession 에서 이미 취소 상태로 기록하고 audit, callback 을 처리했습니다.
+		return
+	case context.DeadlineExceeded:
+		svcErr = SessionTimeoutError(sessionId)
+	default:
+		var ok bool
+		if svcErr, ok = errHandler(err).(*SvcErr); !ok {
+			svcErr = SessionFailedError(err)
+		}
+	}
+	// 그 사이 CancelSession 이 끝낸 session 은 audit, callback 을 다시 보내지 않습니다.
+	if s.sessions.Fail(sessionId, svcErr.Text, svcErr) {
+		s.finishSession(sessionId)
+	}
+}
+
+// finishSession 은 끝난 session 의 결과를 audit log 에 기록하고 appserver 에 알립니다.
+func (s *TSMService) finishSession(sessionId string) {
+	result, ok := s.sessions.Get(sessionId)
+	if !ok {
+		return
+	}
+
+	// MPC session 은 이미 끝나서 되돌릴 수 없으므로 결과를 기록하지 못해도 callback 은 보냅니다.
+	// 시작은 startAudit 에서 기록했으므로 audit log 에는 결과가 없는 session 으로 남고, recordAudit 이 실패를 log 로 남깁니다.
+	s.recordAudit(audit.Entry{
+		SessionId: result.SessionId,
+		KeyId:     result.KeyId,
+		Operation: result.Operation,
+		Caller:    result.Caller,
+		Outcome:   result.Status,
+		Error:     result.Error,
+	})
+
+	event := callback.Event{
+		SessionId:       result.SessionId,
+		PlayerIndex:     s.config.PlayerIndex,
+		Operation:       result.Operation,
+		Status:          result.Status,
+		KeyId:           result.KeyId,
+		PresignatureIds: result.PresignatureIds,
+		ErrorText:       result.ErrorText,
+		Error:           result.Error,
+	}
+	if result.FinishedAt != nil {
+		event.FinishedAt = *result.FinishedAt
+	}
+	go s.callbacks.Notify(event)
+}
@@ -0,0 +1,235 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	PENDING   string = "pending"
+	RUNNING   string = "running"
+	SUCCEEDED string = "succeeded"
+	FAILED    string = "failed"
+	CANCELLED string = "cancelled"
+)
+
+const (
+	GENERATE_KEY string = "generateKey"
+	COPY_KEY     string = "copyKey"
+	PRESIGN      string = "preSign"
+	RESHARE      string = "reshareKey"
+	IMPORT_KEY   string = "importKey"
+)
+
+// finished sessions are kept for this long so the appserver can poll the result.
+const retention = 24 * time.Hour
+
+var (
+	ErrSessionExists   = errors.New("session already exists")
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionFinished = errors.New("session already finished")
+)
+
+type Session struct {
+	SessionId       string     `json:"sessionId" example:"923J-NNcZlScEGi1phSmDWO-eZsQLtBGHVWIIIWZ7Zw"`
+	Operation       string     `json:"operation" example:"generateKey"`
+	Caller          string     `json:"caller,omitempty" example:"appserver"`
+	Status          string     `json:"status" example:"succeeded"`
+	KeyId           string     `json:"keyId,omitempty" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
+	PresignatureIds []string   `json:"presignatureIds,omitempty"`
+	ErrorText       string     `json:"errorText,omitempty" example:"SESSION_FAILED"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	Deadline        time.Time  `json:"deadline"`
+	StartedAt       *time.Time `json:"startedAt,omitempty"`
+	FinishedAt      *time.Time `json:"finishedAt,omitempty"`
+}
+
+func (s *Session) finished() bool {
+	return s.Status == SUCCEEDED || s.Status == FAILED || s.Status == CANCELLED
+}
+
+type Registry struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+	cancels  map[string]context.CancelFunc
+	onFinish func(Session)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		sessions: make(map[string]*Session),
+		cancels:  make(map[string]context.CancelFunc),
+	}
+}
+
+// OnFinish sets a function called once when a session succeeds, fails or is cancelled.
+// It is called with the registry locked, so it must not call the registry.
+func (r *Registry) OnFinish(fn func(Session)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onFinish = fn
+}
+
+// InFlight returns the number of pending or running sessions per operation.
+func (r *Registry) InFlight() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	inFlight := map[string]int{GENERATE_KEY: 0, COPY_KEY: 0, PRESIGN: 0, RESHARE: 0, IMPORT_KEY: 0}
+	for _, s := range r.sessions {
+		if !s.finished() {
+			inFlight[s.Operation]++
+		}
+	}
+	return inFlight
+}
+
+// Create registers a pending session and returns the context its MPC call must run with.
+// The context is done when the timeout passes or the session is cancelled.
+func (r *Registry) Create(sessionId string, operation string, caller string, timeout time.Duration) (context.Context, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune()
+	if _, ok := r.sessions[sessionId]; ok {
+		return nil, ErrSessionExists
+	}
+
+	now := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(timeout))
+	r.cancels[sessionId] = cancel
+	r.sessions[sessionId] = &Session{
+		SessionId: sessionId,
+		Operation: operation,
+		Caller:    caller,
+		Status:    PENDING,
+		CreatedAt: now,
+		Deadline:  now.Add(timeout),
+	}
+	return ctx, nil
+}
+
+func (r *Registry) Start(sessionId string) {
+	r.update(sessionId, func(s *Session) {
+		if s.Status != PENDING {
+			return
+		}
+		now := time.Now()
+		s.Status = RUNNING
+		s.StartedAt = &now
+	})
+}
+
+// Succeed marks the session as succeeded. It returns false if the session was already finished, e.g. cancelled.
+func (r *Registry) Succeed(sessionId string, keyId string, presignatureIds []string) bool {
+	return r.finish(sessionId, func(s *Session) {
+		now := time.Now()
+		s.Status = SUCCEEDED
+		s.KeyId = keyId
+		s.PresignatureIds = presignatureIds
+		s.FinishedAt = &now
+	})
+}
+
+// Fail marks the session as failed. errorText is the error code reported to the caller, such as NODE_UNAVAILABLE.
+// It returns false if the session was already finished.
+func (r *Registry) Fail(sessionId string, errorText string, err error) bool {
+	return r.finish(sessionId, func(s *Session) {
+		now := time.Now()
+		s.Status = FAILED
+		s.ErrorText = errorText
+		s.Error = err.Error()
+		s.FinishedAt = &now
+	})
+}
+
+// Cancel stops a pending or running session. The MPC call sees its context cancelled and returns.
+func (r *Registry) Cancel(sessionId string) (Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[sessionId]
+	if !ok {
+		return Session{}, ErrSessionNotFound
+	}
+	if s.finished() {
+		return *s, ErrSessionFinished
+	}
+
+	now := time.Now()
+	s.Status = CANCELLED
+	s.FinishedAt = &now
+	r.release(sessionId)
+	r.notify(s)
+	return *s, nil
+}
+
+// Get returns a copy of the session so callers can't race with the background goroutine.
+func (r *Registry) Get(sessionId string) (Session, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.sessions[sessionId]
+	if !ok {
+		return Session{}, false
+	}
+
+	session := *s
+	session.PresignatureIds = append([]string(nil), s.PresignatureIds...)
+	return session, true
+}
+
+func (r *Registry) update(sessionId string, fn func(s *Session)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.sessions[sessionId]; ok {
+		fn(s)
+	}
+}
+
+// finish updates a session that is not finished yet and releases its context.
+// a cancelled session keeps its status even if the MPC call returns afterwards.
+// It returns true if the session was updated.
+func (r *Registry) finish(sessionId string, fn func(s *Session)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	defer r.release(sessionId)
+	s, ok := r.sessions[sessionId]
+	if !ok || s.finished() {
+		return false
+	}
+	fn(s)
+	r.notify(s)
+	return true
+}
+
+func (r *Registry) notify(s *Session) {
+	// caller must hold r.mu
+	if r.onFinish != nil {
+		r.onFinish(*s)
+	}
+}
+
+func (r *Registry) release(sessionId string) {
+	// caller must hold r.mu
+	if cancel, ok := r.cancels[sessionId]; ok {
+		cancel()
+		delete(r.cancels, sessionId)
+	}
+}
+
+func (r *Registry) prune() {
+	// caller must hold r.mu
+	cutoff := time.Now().Add(-retention)
+	for id, s := range r.sessions {
+		if s.finished() && s.FinishedAt.Before(cutoff) {
+			delete(r.sessions, id)
+		}
+	}
+}
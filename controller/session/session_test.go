@@ -0,0 +1,158 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func create(t *testing.T, registry *Registry, sessionId string, timeout time.Duration) context.Context {
+	t.Helper()
+	ctx, err := registry.Create(sessionId, GENERATE_KEY, "appserver", timeout)
+	if err != nil {
+		t.Fatalf("Create(%s) error: %v", sessionId, err)
+	}
+	return ctx
+}
+
+func TestSessionSucceeds(t *testing.T) {
+	registry := NewRegistry()
+	var finished []Session
+	registry.OnFinish(func(s Session) { finished = append(finished, s) })
+
+	ctx := create(t, registry, "s", time.Minute)
+	if s, _ := registry.Get("s"); s.Status != PENDING {
+		t.Errorf("status after Create = %s, want %s", s.Status, PENDING)
+	}
+	registry.Start("s")
+	if s, _ := registry.Get("s"); s.Status != RUNNING || s.StartedAt == nil {
+		t.Errorf("status after Start = %s, startedAt = %v", s.Status, s.StartedAt)
+	}
+
+	if !registry.Succeed("s", "k", []string{"p1"}) {
+		t.Fatal("Succeed returned false")
+	}
+	s, _ := registry.Get("s")
+	if s.Status != SUCCEEDED || s.KeyId != "k" || s.FinishedAt == nil {
+		t.Errorf("session = %+v", s)
+	}
+	if ctx.Err() == nil {
+		t.Error("context is not released after the session finished")
+	}
+	// 끝난 session 은 다시 끝낼 수 없습니다.
+	if registry.Fail("s", "SESSION_FAILED", errors.New("late error")) {
+		t.Error("Fail after Succeed returned true")
+	}
+	if len(finished) != 1 || finished[0].Status != SUCCEEDED {
+		t.Errorf("OnFinish calls = %+v, want one %s", finished, SUCCEEDED)
+	}
+}
+
+func TestSessionFails(t *testing.T) {
+	registry := NewRegistry()
+	create(t, registry, "s", time.Minute)
+
+	if !registry.Fail("s", "NODE_UNAVAILABLE", errors.New("connection refused")) {
+		t.Fatal("Fail returned false")
+	}
+	s, _ := registry.Get("s")
+	if s.Status != FAILED || s.ErrorText != "NODE_UNAVAILABLE" || s.Error != "connection refused" {
+		t.Errorf("session = %+v", s)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	registry := NewRegistry()
+	ctx := create(t, registry, "s", time.Minute)
+	registry.Start("s")
+
+	s, err := registry.Cancel("s")
+	if err != nil || s.Status != CANCELLED {
+		t.Fatalf("Cancel = %s, %v", s.Status, err)
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("context error = %v, want %v", ctx.Err(), context.Canceled)
+	}
+	// MPC 호출이 취소된 다음 돌아와도 cancelled 상태를 유지합니다.
+	if registry.Succeed("s", "k", nil) {
+		t.Error("Succeed after Cancel returned true")
+	}
+	if s, _ := registry.Get("s"); s.Status != CANCELLED {
+		t.Errorf("status = %s, want %s", s.Status, CANCELLED)
+	}
+
+	if _, err := registry.Cancel("s"); !errors.Is(err, ErrSessionFinished) {
+		t.Errorf("second Cancel error = %v, want %v", err, ErrSessionFinished)
+	}
+	if _, err := registry.Cancel("unknown"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Cancel(unknown) error = %v, want %v", err, ErrSessionNotFound)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	registry := NewRegistry()
+	ctx := create(t, registry, "s", 10*time.Millisecond)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context is not done after the timeout")
+	}
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("context error = %v, want %v", ctx.Err(), context.DeadlineExceeded)
+	}
+}
+
+func TestCreateRejectsDuplicate(t *testing.T) {
+	registry := NewRegistry()
+	create(t, registry, "s", time.Minute)
+	if _, err := registry.Create("s", COPY_KEY, "appserver", time.Minute); !errors.Is(err, ErrSessionExists) {
+		t.Errorf("error = %v, want %v", err, ErrSessionExists)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	registry := NewRegistry()
+	create(t, registry, "old", time.Minute)
+	create(t, registry, "recent", time.Minute)
+	create(t, registry, "running", time.Minute)
+	registry.Succeed("old", "k", nil)
+	registry.Succeed("recent", "k", nil)
+
+	expired := time.Now().Add(-retention - time.Minute)
+	registry.sessions["old"].FinishedAt = &expired
+	registry.sessions["running"].CreatedAt = expired
+
+	// 새 session 을 만들 때 보관 기간이 지난 session 을 지웁니다.
+	create(t, registry, "new", time.Minute)
+	for sessionId, kept := range map[string]bool{"old": false, "recent": true, "running": true, "new": true} {
+		if _, ok := registry.Get(sessionId); ok != kept {
+			t.Errorf("%s kept = %v, want %v", sessionId, ok, kept)
+		}
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	registry := NewRegistry()
+	create(t, registry, "a", time.Minute)
+	create(t, registry, "b", time.Minute)
+	registry.Cancel("b")
+
+	inFlight := registry.InFlight()
+	if inFlight[GENERATE_KEY] != 1 || inFlight[PRESIGN] != 0 {
+		t.Errorf("InFlight = %v", inFlight)
+	}
+}
+
+func TestGetReturnsCopy(t *testing.T) {
+	registry := NewRegistry()
+	create(t, registry, "s", time.Minute)
+	registry.Succeed("s", "k", []string{"p1"})
+
+	s, _ := registry.Get("s")
+	s.PresignatureIds[0] = "changed"
+	if stored, _ := registry.Get("s"); stored.PresignatureIds[0] != "p1" {
+		t.Error("stored session was changed through a returned copy")
+	}
+}
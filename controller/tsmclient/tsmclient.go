@@ -0,0 +1,146 @@
+package tsmclient
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
+
+	"github.com/ahnlabio/tsm-controller/tsmutils"
+)
+
+const DEFAULT_HEALTH_CHECK_INTERVAL = 30 * time.Second
+
+// health check 한 번에 허용하는 시간
+const healthCheckTimeout = 5 * time.Second
+
+// Settings are the node connection settings a client is built from.
+type Settings struct {
+	URL    string
+	APIKey string
+}
+
+// Manager owns one long-lived tsm.Client shared by all requests.
+// The client is rebuilt when the settings change or when a health check fails.
+type Manager struct {
+	mu       sync.RWMutex
+	client   *tsm.Client
+	settings Settings
+	healthy  bool
+
+	load     func() Settings
+	interval time.Duration
+}
+
+// NewManager creates the client from load() and starts the periodic health check.
+// load is called on every check so changed settings are picked up without a restart.
+func NewManager(load func() Settings, interval time.Duration) *Manager {
+	if interval <= 0 {
+		interval = DEFAULT_HEALTH_CHECK_INTERVAL
+	}
+	m := &Manager{load: load, interval: interval}
+
+	// 시작 시점에 node 가 준비되지 않았을 수 있으므로 실패해도 요청 시점에 다시 생성합니다.
+	if _, err := m.Client(); err != nil {
+		log.Printf("[tsmclient] failed to create client. error: %v", err)
+	}
+	go m.run()
+	return m
+}
+
+// Client returns the shared client, creating it if there is none yet.
+func (m *Manager) Client() (*tsm.Client, error) {
+	m.mu.RLock()
+	client := m.client
+	m.mu.RUnlock()
+	if client != nil {
+		return client, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client != nil {
+		return m.client, nil
+	}
+	return m.rebuild(m.load())
+}
+
+// Healthy reports the result of the last health check.
+func (m *Manager) Healthy() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.healthy
+}
+
+func (m *Manager) run() {
+	// readiness 가 첫 interval 까지 기다리지 않도록 시작하자마자 확인합니다.
+	m.check()
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.check()
+	}
+}
+
+func (m *Manager) check() {
+	settings := m.load()
+
+	m.mu.RLock()
+	client := m.client
+	changed := settings != m.settings
+	m.mu.RUnlock()
+
+	if client == nil || changed {
+		if changed {
+			log.Printf("[tsmclient] node settings changed. url: %s", settings.URL)
+		}
+		m.mu.Lock()
+		_, err := m.rebuild(settings)
+		m.mu.Unlock()
+		if err != nil {
+			log.Printf("[tsmclient] failed to rebuild client. error: %v", err)
+			return
+		}
+		m.mu.RLock()
+		client = m.client
+		m.mu.RUnlock()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	_, err := client.WrappingKey().Fingerprint(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		if !m.healthy {
+			log.Printf("[tsmclient] node is healthy. url: %s", m.settings.URL)
+		}
+		m.healthy = true
+		return
+	}
+
+	log.Printf("[tsmclient] health check failed. url: %s, error: %v", m.settings.URL, err)
+	m.healthy = false
+	// 연결 상태가 깨졌을 수 있으므로 다음 요청부터 새 client 를 사용합니다.
+	if m.client == client {
+		if _, err := m.rebuild(settings); err != nil {
+			log.Printf("[tsmclient] failed to rebuild client. error: %v", err)
+		}
+	}
+}
+
+// rebuild must be called with m.mu held.
+func (m *Manager) rebuild(settings Settings) (*tsm.Client, error) {
+	tsmConfig := tsm.Configuration{URL: settings.URL}.WithAPIKeyAuthentication(settings.APIKey)
+	client, err := tsmutils.GetClientFromConfig(tsmConfig)
+	if err != nil {
+		return nil, err
+	}
+	m.client = client
+	m.settings = settings
+	log.Printf("[tsmclient] created client. url: %s", settings.URL)
+	return client, nil
+}
@@ -0,0 +1,124 @@
+package tsmutils
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
+)
+
+const (
+	SCHNORR string = "schnorr"
+	ECDSA   string = "ecdsa"
+)
+
+// DEFAULT_KEY_POLICY is used when KEY_POLICY is not configured.
+const DEFAULT_KEY_POLICY string = "schnorr:ED-25519:1,ecdsa:secp256k1:1,ecdsa:P-256:1"
+
+// KeySpec is an algorithm, curve and threshold combination a key can be generated with.
+type KeySpec struct {
+	Algorithm string
+	Curve     string
+	Threshold int
+}
+
+func (k KeySpec) String() string {
+	return fmt.Sprintf("%s:%s:%d", k.Algorithm, k.Curve, k.Threshold)
+}
+
+// ParseKeyPolicy parses a comma separated list of algorithm:curve:threshold entries.
+func ParseKeyPolicy(policy string) ([]KeySpec, error) {
+	var specs []KeySpec
+	for _, entry := range strings.Split(policy, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid key policy entry: %s", entry)
+		}
+		if _, err := DefaultCurve(parts[0]); err != nil {
+			return nil, err
+		}
+		threshold, err := strconv.Atoi(parts[2])
+		if err != nil || threshold < 1 {
+			return nil, fmt.Errorf("invalid threshold in key policy entry: %s", entry)
+		}
+
+		specs = append(specs, KeySpec{Algorithm: parts[0], Curve: parts[1], Threshold: threshold})
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("key policy is empty")
+	}
+	return specs, nil
+}
+
+type PartialSignResult struct {
+	PresignatureID   string
+	PartialSignature []byte
+}
+
+// KeyAPI 는 SDK 의 Schnorr, ECDSA API 중 service 에서 사용하는 부분을 같은 형태로 제공합니다.
+type KeyAPI interface {
+	GenerateKey(ctx context.Context, sessionConfig *tsm.SessionConfig, threshold int, curveName string, desiredKeyID string) (string, error)
+	CopyKey(ctx context.Context, sessionConfig *tsm.SessionConfig, keyID string, curveName string, newThreshold int, desiredKeyID string) (string, error)
+	Reshare(ctx context.Context, sessionConfig *tsm.SessionConfig, keyID string) error
+	ImportKeyShares(ctx context.Context, sessionConfig *tsm.SessionConfig, threshold int, wrappedKeyShare []byte, wrappedChainCode []byte, pkixPublicKey []byte, desiredKeyID string) (string, error)
+	GeneratePresignatures(ctx context.Context, sessionConfig *tsm.SessionConfig, keyID string, presignatureCount uint64) ([]string, error)
+	SignWithPresignature(ctx context.Context, keyID string, presignatureID string, derivationPath []uint32, message []byte) (*PartialSignResult, error)
+	PublicKey(ctx context.Context, keyID string, derivationPath []uint32) ([]byte, error)
+	BackupKeyShare(ctx context.Context, keyID string) ([]byte, error)
+	GenerateRecoveryData(ctx context.Context, sessionConfig *tsm.SessionConfig, keyID string, ersPublicKey *rsa.PublicKey, ersLabel []byte) ([]byte, error)
+}
+
+// GetKeyAPI returns the SDK API for the algorithm. empty algorithm means schnorr.
+func GetKeyAPI(client *tsm.Client, algorithm string) (KeyAPI, error) {
+	switch algorithm {
+	case "", SCHNORR:
+		return schnorrKeyAPI{client.Schnorr()}, nil
+	case ECDSA:
+		return ecdsaKeyAPI{client.ECDSA()}, nil
+	}
+	return nil, UnsupportedAlgorithmError(algorithm)
+}
+
+// DefaultCurve returns the curve used when the request doesn't specify one.
+func DefaultCurve(algorithm string) (string, error) {
+	switch algorithm {
+	case "", SCHNORR:
+		return "ED-25519", nil
+	case ECDSA:
+		return "secp256k1", nil
+	}
+	return "", UnsupportedAlgorithmError(algorithm)
+}
+
+type schnorrKeyAPI struct {
+	tsm.SchnorrAPI
+}
+
+func (a schnorrKeyAPI) SignWithPresignature(ctx context.Context, keyID string, presignatureID string, derivationPath []uint32, message []byte) (*PartialSignResult, error) {
+	result, err := a.SchnorrAPI.SignWithPresignature(ctx, keyID, presignatureID, derivationPath, message)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialSignResult{PresignatureID: result.PresignatureID, PartialSignature: result.PartialSignature}, nil
+}
+
+type ecdsaKeyAPI struct {
+	tsm.ECDSAAPI
+}
+
+func (a ecdsaKeyAPI) SignWithPresignature(ctx context.Context, keyID string, presignatureID string, derivationPath []uint32, messageHash []byte) (*PartialSignResult, error) {
+	result, err := a.ECDSAAPI.SignWithPresignature(ctx, keyID, presignatureID, derivationPath, messageHash)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialSignResult{PresignatureID: result.PresignatureID, PartialSignature: result.PartialSignature}, nil
+}
@@ -1,11 +1,24 @@
 package tsmutils
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
+)
 
 type ErrorString string
 
 const (
-	DECODING_ERROR string = "DECODING_ERROR"
+	DECODING_ERROR          string = "DECODING_ERROR"
+	UNSUPPORTED_ALGORITHM   string = "UNSUPPORTED_ALGORITHM"
+	INVALID_DERIVATION_PATH string = "INVALID_DERIVATION_PATH"
+	INVALID_PUBLIC_KEY      string = "INVALID_PUBLIC_KEY"
+	WRONG_ROLE              string = "WRONG_ROLE"
+	NODE_UNAVAILABLE        string = "NODE_UNAVAILABLE"
+	INVALID_SIGN_REQUEST    string = "INVALID_SIGN_REQUEST"
 )
 
 var (
@@ -27,3 +40,78 @@ func DecodingError(err error) *TsmUtilsErr {
 		Msg:  err.Error(),
 	}
 }
+
+func UnsupportedAlgorithmError(algorithm string) *TsmUtilsErr {
+	return &TsmUtilsErr{
+		Text: UNSUPPORTED_ALGORITHM,
+		Msg:  fmt.Sprintf("unsupported algorithm: %s", algorithm),
+	}
+}
+
+func InvalidDerivationPathError(err error) *TsmUtilsErr {
+	return &TsmUtilsErr{
+		Text: INVALID_DERIVATION_PATH,
+		Msg:  err.Error(),
+	}
+}
+
+func InvalidPublicKeyError(err error) *TsmUtilsErr {
+	return &TsmUtilsErr{
+		Text: INVALID_PUBLIC_KEY,
+		Msg:  err.Error(),
+	}
+}
+
+func InvalidSignRequestError(err error) *TsmUtilsErr {
+	return &TsmUtilsErr{
+		Text: INVALID_SIGN_REQUEST,
+		Msg:  err.Error(),
+	}
+}
+
+func WrongRoleError(playerIndex string, operation string) *TsmUtilsErr {
+	return &TsmUtilsErr{
+		Text: WRONG_ROLE,
+		Msg:  fmt.Sprintf("player %s is not allowed for %s", playerIndex, operation),
+	}
+}
+
+func NodeUnavailableError(err error) *TsmUtilsErr {
+	return &TsmUtilsErr{
+		Text: NODE_UNAVAILABLE,
+		Msg:  err.Error(),
+	}
+}
+
+// SDK 는 전송 오류를 %s 로 감싸므로 errors.Is 로 구분할 수 없어 메시지로 확인합니다.
+// 응답을 해석하지 못한 error 까지 포함하지 않도록 "EOF" 같은 짧은 메시지는 확인하지 않습니다.
+var unavailableMessages = []string{
+	"connection refused",
+	"connection reset",
+	"no such host",
+	"i/o timeout",
+	"context deadline exceeded",
+}
+
+// IsNodeUnavailable reports whether err means the TSM node could not be reached or is not ready.
+// Authentication errors are not included because a wrong NODE_API_KEY doesn't go away by retrying.
+func IsNodeUnavailable(err error) bool {
+	if errors.Is(err, tsm.ErrUnavailable) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	for _, message := range unavailableMessages {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKeyNotFound reports whether the TSM node rejected the request because the key does not exist.
+func IsKeyNotFound(err error) bool {
+	if !errors.Is(err, tsm.ErrInvalidInput) {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "node returned 404") || strings.Contains(message, "key not found")
+}
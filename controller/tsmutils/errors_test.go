@@ -0,0 +1,31 @@
+package tsmutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
+)
+
+func TestIsNodeUnavailable(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		unavailable bool
+	}{
+		{"node returned 503", fmt.Errorf("%w ; node returned 503: starting", tsm.ErrUnavailable), true},
+		{"connection refused", fmt.Errorf("%w: dial tcp 127.0.0.1:8500: connect: connection refused", tsm.ErrOperationFailed), true},
+		{"connection closed", fmt.Errorf("read response: %w", io.EOF), true},
+		{"truncated response", fmt.Errorf("read response: %w", io.ErrUnexpectedEOF), true},
+		{"wrong api key", fmt.Errorf("%w ; node returned 401: unauthorized", tsm.ErrAuthentication), false},
+		{"decode error mentioning EOF", errors.New("invalid character 'E' looking for beginning of value: EOF"), false},
+		{"invalid input", fmt.Errorf("%w ; node returned 400: bad request", tsm.ErrInvalidInput), false},
+	}
+	for _, test := range tests {
+		if got := IsNodeUnavailable(test.err); got != test.unavailable {
+			t.Errorf("%s: IsNodeUnavailable = %v, want %v", test.name, got, test.unavailable)
+		}
+	}
+}
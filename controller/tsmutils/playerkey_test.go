@@ -0,0 +1,71 @@
+package tsmutils
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+)
+
+func marshalPublicKey(t *testing.T, publicKey any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestParsePlayerPublicKey(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der := marshalPublicKey(t, &privateKey.PublicKey)
+
+	got, err := ParsePlayerPublicKey(base64.StdEncoding.EncodeToString(der))
+	if err != nil {
+		t.Fatalf("ParsePlayerPublicKey error: %v", err)
+	}
+	if !bytes.Equal(got, der) {
+		t.Errorf("ParsePlayerPublicKey returned %x, want %x", got, der)
+	}
+}
+
+func TestParsePlayerPublicKeyRejectsInvalidKeys(t *testing.T) {
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// point 의 마지막 byte 를 바꾸면 curve 위에 있지 않습니다.
+	offCurve := marshalPublicKey(t, &p256Key.PublicKey)
+	offCurve[len(offCurve)-1] ^= 0x01
+
+	tests := map[string]string{
+		"empty":          "",
+		"not base64":     "not base64!",
+		"unpadded":       base64.RawStdEncoding.EncodeToString(marshalPublicKey(t, &p256Key.PublicKey)),
+		"not spki":       base64.StdEncoding.EncodeToString([]byte("not a SubjectPublicKeyInfo")),
+		"p-384":          base64.StdEncoding.EncodeToString(marshalPublicKey(t, &p384Key.PublicKey)),
+		"ed25519":        base64.StdEncoding.EncodeToString(marshalPublicKey(t, ed25519Key)),
+		"point off p256": base64.StdEncoding.EncodeToString(offCurve),
+	}
+	for name, publicKey := range tests {
+		_, err := ParsePlayerPublicKey(publicKey)
+		if errorText(err) != INVALID_PUBLIC_KEY {
+			t.Errorf("%s: error = %v, want %s", name, err, INVALID_PUBLIC_KEY)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package tsmutils
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mr-tron/base58"
+)
+
+// SDK 가 허용하는 non-hardened derivation path 의 최대 깊이
+const maxDerivationPathDepth = 50
+
+// BIP32 hardened index 의 시작값
+const hardenedKeyStart uint32 = 1 << 31
+
+type PublicKeyInfo struct {
+	PKIX   string `json:"pkix" example:"MCowBQYDK2VwAyEA1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="`
+	Hex    string `json:"hex" example:"d61bf425f83d54872146da73584ac7207735cfd1047fc77d9b8a10e86fcbc0e8"`
+	Base64 string `json:"base64" example:"1hv0Jfg9VIchRtpzWErHIHc1z9EEf8d9m4oQ6G/LwOg="`
+	Base58 string `json:"base58" example:"FQnyF8mUwgUapn3mjrcoCsURrmawEitKsMHkWfdYKtGP"`
+}
+
+// ParseDerivationPath parses a BIP32 style path such as "m/44/0/1".
+// Only non-hardened paths are supported by the TSM, so hardened elements are rejected.
+// An empty path returns nil, which means the master key.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "m")
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return nil, nil
+	}
+
+	elements := strings.Split(path, "/")
+	derivationPath := make([]uint32, len(elements))
+	for i, element := range elements {
+		if strings.HasSuffix(element, "'") || strings.HasSuffix(element, "h") {
+			return nil, InvalidDerivationPathError(fmt.Errorf("hardened derivation is not supported: %s", element))
+		}
+		value, err := strconv.ParseUint(element, 10, 31)
+		if err != nil {
+			return nil, InvalidDerivationPathError(fmt.Errorf("invalid derivation path element: %s", element))
+		}
+		derivationPath[i] = uint32(value)
+	}
+
+	if err := ValidateDerivationPath(derivationPath); err != nil {
+		return nil, err
+	}
+	return derivationPath, nil
+}
+
+// ValidateDerivationPath checks that the path is a non-hardened path the TSM accepts.
+func ValidateDerivationPath(derivationPath []uint32) error {
+	if len(derivationPath) > maxDerivationPathDepth {
+		return InvalidDerivationPathError(fmt.Errorf("derivation path is deeper than %d", maxDerivationPathDepth))
+	}
+	for _, element := range derivationPath {
+		if element >= hardenedKeyStart {
+			return InvalidDerivationPathError(fmt.Errorf("hardened derivation is not supported: %d", element))
+		}
+	}
+	return nil
+}
+
+// GetPublicKeyInfo returns the encodings of the raw public key contained in a PKIX (SubjectPublicKeyInfo) public key.
+// Ed25519 keys are 32 bytes, EC keys are the point as encoded by the TSM.
+func GetPublicKeyInfo(pkixPublicKey []byte) (*PublicKeyInfo, error) {
+	var subjectPublicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(pkixPublicKey, &subjectPublicKeyInfo); err != nil {
+		return nil, DecodingError(err)
+	}
+
+	rawPublicKey := subjectPublicKeyInfo.PublicKey.RightAlign()
+	return &PublicKeyInfo{
+		PKIX:   base64.StdEncoding.EncodeToString(pkixPublicKey),
+		Hex:    hex.EncodeToString(rawPublicKey),
+		Base64: base64.StdEncoding.EncodeToString(rawPublicKey),
+		Base58: base58.Encode(rawPublicKey),
+	}, nil
+}
+
+var (
+	oidEd25519     = asn1.ObjectIdentifier{1, 3, 101, 112}
+	oidEd448       = asn1.ObjectIdentifier{1, 3, 101, 113}
+	oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// KEY_POLICY 의 curve 이름
+var namedCurves = map[string]string{
+	"1.3.132.0.10":        "secp256k1",
+	"1.2.840.10045.3.1.7": "P-256",
+}
+
+// PKIXCurve returns the curve name, as used in KEY_POLICY, of a PKIX (SubjectPublicKeyInfo) public key.
+func PKIXCurve(pkixPublicKey []byte) (string, error) {
+	var subjectPublicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(pkixPublicKey, &subjectPublicKeyInfo); err != nil {
+		return "", InvalidPublicKeyError(err)
+	}
+
+	algorithm := subjectPublicKeyInfo.Algorithm
+	switch {
+	case algorithm.Algorithm.Equal(oidEd25519):
+		return "ED-25519", nil
+	case algorithm.Algorithm.Equal(oidEd448):
+		return "ED-448", nil
+	case algorithm.Algorithm.Equal(oidECPublicKey):
+		var namedCurve asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, &namedCurve); err != nil {
+			return "", InvalidPublicKeyError(err)
+		}
+		if curveName, ok := namedCurves[namedCurve.String()]; ok {
+			return curveName, nil
+		}
+		return "", InvalidPublicKeyError(fmt.Errorf("unsupported curve: %s", namedCurve))
+	}
+	return "", InvalidPublicKeyError(fmt.Errorf("unsupported public key algorithm: %s", algorithm.Algorithm))
+}
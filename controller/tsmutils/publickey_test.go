@@ -0,0 +1,76 @@
+package tsmutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+// errorText returns the error code of a tsmutils error, or "" for any other error.
+func errorText(err error) string {
+	if tsmUtilsErr, ok := err.(*TsmUtilsErr); ok {
+		return tsmUtilsErr.Text
+	}
+	return ""
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []uint32
+	}{
+		{"", nil},
+		{"m", nil},
+		{"m/", nil},
+		{" m/44/0/1 ", []uint32{44, 0, 1}},
+		{"44/0", []uint32{44, 0}},
+		{"m/2147483647", []uint32{2147483647}},
+	}
+	for _, test := range tests {
+		got, err := ParseDerivationPath(test.path)
+		if err != nil {
+			t.Errorf("ParseDerivationPath(%q) error: %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseDerivationPath(%q) = %v, want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestParseDerivationPathRejectsInvalidPaths(t *testing.T) {
+	deep := "m"
+	for i := 0; i <= maxDerivationPathDepth; i++ {
+		deep += "/0"
+	}
+
+	for _, path := range []string{
+		"m/44'/0",
+		"m/44h",
+		"m/2147483648",
+		"m/-1",
+		"m/a",
+		"m//1",
+		"m/1/",
+		deep,
+	} {
+		_, err := ParseDerivationPath(path)
+		if errorText(err) != INVALID_DERIVATION_PATH {
+			t.Errorf("ParseDerivationPath(%q) error = %v, want %v", path, err, INVALID_DERIVATION_PATH)
+		}
+	}
+}
+
+func TestValidateDerivationPath(t *testing.T) {
+	if err := ValidateDerivationPath(nil); err != nil {
+		t.Errorf("ValidateDerivationPath(nil) error: %v", err)
+	}
+	if err := ValidateDerivationPath(make([]uint32, maxDerivationPathDepth)); err != nil {
+		t.Errorf("ValidateDerivationPath(depth %d) error: %v", maxDerivationPathDepth, err)
+	}
+	if err := ValidateDerivationPath(make([]uint32, maxDerivationPathDepth+1)); errorText(err) != INVALID_DERIVATION_PATH {
+		t.Errorf("ValidateDerivationPath(depth %d) error = %v, want %v", maxDerivationPathDepth+1, err, INVALID_DERIVATION_PATH)
+	}
+	if err := ValidateDerivationPath([]uint32{44, hardenedKeyStart}); errorText(err) != INVALID_DERIVATION_PATH {
+		t.Errorf("ValidateDerivationPath(hardened) error = %v, want %v", err, INVALID_DERIVATION_PATH)
+	}
+}
@@ -0,0 +1,51 @@
+package tsmutils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+const (
+	SIGN_MODE_HASH    string = "hash"
+	SIGN_MODE_MESSAGE string = "message"
+)
+
+// SignData returns the bytes the TSM signs.
+// hash mode (default) signs a digest the caller computed.
+// message mode signs the raw message, which pure Ed25519 verifiers such as crypto/ed25519 expect. schnorr only.
+func SignData(mode string, messageHash string, message string, algorithm string) ([]byte, error) {
+	switch mode {
+	case "", SIGN_MODE_HASH:
+		if messageHash == "" || message != "" {
+			return nil, InvalidSignRequestError(fmt.Errorf("hash mode requires messageHash and no message"))
+		}
+		messageHashBytes, err := base64.StdEncoding.DecodeString(messageHash)
+		if err != nil {
+			return nil, InvalidSignRequestError(fmt.Errorf("messageHash is not base64: %w", err))
+		}
+		return messageHashBytes, nil
+	case SIGN_MODE_MESSAGE:
+		if message == "" || messageHash != "" {
+			return nil, InvalidSignRequestError(fmt.Errorf("message mode requires message and no messageHash"))
+		}
+		if algorithm == ECDSA {
+			return nil, InvalidSignRequestError(fmt.Errorf("message mode is not supported for ecdsa. sign a hash instead"))
+		}
+		messageBytes, err := base64.StdEncoding.DecodeString(message)
+		if err != nil {
+			return nil, InvalidSignRequestError(fmt.Errorf("message is not base64: %w", err))
+		}
+		return messageBytes, nil
+	}
+	return nil, InvalidSignRequestError(fmt.Errorf("unsupported sign mode: %s", mode))
+}
+
+// PolicyHash returns the hash a signing policy checks. In message mode it is sha256 of the message.
+func PolicyHash(mode string, signData []byte) []byte {
+	if mode == SIGN_MODE_MESSAGE {
+		hash := sha256.Sum256(signData)
+		return hash[:]
+	}
+	return signData
+}
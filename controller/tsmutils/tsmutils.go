@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"fmt"
 	"log"
 
 	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
@@ -16,17 +17,23 @@ type NodeConfig struct {
 	AnotherNodePublicKey string // another server node public key
 }
 
-func GetClientFromConfig(config *tsm.Configuration) *tsm.Client {
+func GetClientFromConfig(config *tsm.Configuration) (*tsm.Client, error) {
 	client, err := tsm.NewClient(config)
 	if err != nil {
-		panic(err)
+		return nil, NodeUnavailableError(err)
 	}
-	return client
+	return client, nil
 }
 
 func CreateSignSessionConfig(sessionId string, nodeConfig NodeConfig) (*tsm.SessionConfig, error) {
-	player0PublicKey := getPublicKeyBytesFromString(nodeConfig.Player0PublicKey)
-	player1PublicKey := getPublicKeyBytesFromString(nodeConfig.NodePubicKey)
+	player0PublicKey, err := ParsePlayerPublicKey(nodeConfig.Player0PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	player1PublicKey, err := getPublicKeyBytesFromString(nodeConfig.NodePubicKey)
+	if err != nil {
+		return nil, err
+	}
 	dynamicPublicKeys := map[int][]byte{
 		0: player0PublicKey,
 		1: player1PublicKey,
@@ -39,7 +46,10 @@ func CreateSignSessionConfig(sessionId string, nodeConfig NodeConfig) (*tsm.Sess
 }
 
 func CreateKeySessionConfig(sessionId string, nodeConfig NodeConfig) (*tsm.SessionConfig, error) {
-	dynamicPublicKeys := getDynamicPublicKeys(nodeConfig)
+	dynamicPublicKeys, err := getDynamicPublicKeys(nodeConfig)
+	if err != nil {
+		return nil, err
+	}
 	dumpPublicKeys(dynamicPublicKeys)
 
 	var players []int = []int{0, 1, 2}
@@ -48,13 +58,57 @@ func CreateKeySessionConfig(sessionId string, nodeConfig NodeConfig) (*tsm.Sessi
 	return sessionConfig, nil
 }
 
-func getDynamicPublicKeys(config NodeConfig) map[int][]byte {
-	nodeIndex := getNodeIndex(config.PlayerIndex)
-	anotherNodeIndex := getOtherNodeIndex(config.PlayerIndex)
+// CreateServerSessionConfig creates a session of player 1 and 2 only. the mobile player (player 0) doesn't take part.
+func CreateServerSessionConfig(sessionId string, nodeConfig NodeConfig) (*tsm.SessionConfig, error) {
+	nodeIndex, err := getNodeIndex(nodeConfig.PlayerIndex)
+	if err != nil {
+		return nil, err
+	}
+	anotherNodeIndex, err := getOtherNodeIndex(nodeConfig.PlayerIndex)
+	if err != nil {
+		return nil, err
+	}
+	nodePublicKeyBytes, err := getPublicKeyBytesFromString(nodeConfig.NodePubicKey)
+	if err != nil {
+		return nil, err
+	}
+	anotherPublicKeyBytes, err := getPublicKeyBytesFromString(nodeConfig.AnotherNodePublicKey)
+	if err != nil {
+		return nil, err
+	}
+	dynamicPublicKeys := map[int][]byte{
+		nodeIndex:        nodePublicKeyBytes,
+		anotherNodeIndex: anotherPublicKeyBytes,
+	}
+
+	var players []int = []int{1, 2}
+	log.Printf("[tsmutils] CreateServerSessionConfig. dynamic public keys: %v", dynamicPublicKeys)
+	sessionConfig := tsm.NewSessionConfig(sessionId, players, dynamicPublicKeys)
+	return sessionConfig, nil
+}
+
+func getDynamicPublicKeys(config NodeConfig) (map[int][]byte, error) {
+	nodeIndex, err := getNodeIndex(config.PlayerIndex)
+	if err != nil {
+		return nil, err
+	}
+	anotherNodeIndex, err := getOtherNodeIndex(config.PlayerIndex)
+	if err != nil {
+		return nil, err
+	}
 
-	player0PublicKeyBytes := getPublicKeyBytesFromString(config.Player0PublicKey)
-	nodePublicKeyBytes := getPublicKeyBytesFromString(config.NodePubicKey)
-	anotherPublicKeyBytes := getPublicKeyBytesFromString(config.AnotherNodePublicKey)
+	player0PublicKeyBytes, err := ParsePlayerPublicKey(config.Player0PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	nodePublicKeyBytes, err := getPublicKeyBytesFromString(config.NodePubicKey)
+	if err != nil {
+		return nil, err
+	}
+	anotherPublicKeyBytes, err := getPublicKeyBytesFromString(config.AnotherNodePublicKey)
+	if err != nil {
+		return nil, err
+	}
 
 	dynamicPublicKeys := map[int][]byte{
 		0:                player0PublicKeyBytes,
@@ -62,38 +116,40 @@ func getDynamicPublicKeys(config NodeConfig) map[int][]byte {
 		anotherNodeIndex: anotherPublicKeyBytes,
 	}
 
-	return dynamicPublicKeys
+	return dynamicPublicKeys, nil
 }
 
-func getPublicKeyBytesFromString(publicKey string) []byte {
+// getPublicKeyBytesFromString decodes a node public key from the configuration.
+// node public key 는 설정 오류이므로 요청자의 잘못(INVALID_INPUT)으로 처리하지 않습니다.
+func getPublicKeyBytesFromString(publicKey string) ([]byte, error) {
 	publicKeyBytes, err := base64.StdEncoding.DecodeString(publicKey)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("invalid node public key in configuration: %w", err)
 	}
 
-	return publicKeyBytes
+	return publicKeyBytes, nil
 }
 
-func getNodeIndex(playerIndex string) int {
+func getNodeIndex(playerIndex string) (int, error) {
 	switch playerIndex {
 	case "1":
-		return 1
+		return 1, nil
 	case "2":
-		return 2
+		return 2, nil
 	}
 	log.Printf("[ERROR] invalid playerIndex: %s", playerIndex)
-	panic("invalid player index")
+	return 0, WrongRoleError(playerIndex, "key session")
 }
 
-func getOtherNodeIndex(playerIndex string) int {
+func getOtherNodeIndex(playerIndex string) (int, error) {
 	switch playerIndex {
 	case "1":
-		return 2
+		return 2, nil
 	case "2":
-		return 1
+		return 1, nil
 	}
 	log.Printf("[ERROR] invalid playerIndex: %s", playerIndex)
-	panic("invalid player index")
+	return 0, WrongRoleError(playerIndex, "key session")
 }
 
 func dumpPublicKeys(publicKeys map[int][]byte) {
@@ -1,9 +1,11 @@
 module example.com
 
-require gitlab.com/Blockdaemon/go-tsm-sdkv2/v64 v64.0.0
+require (
+	filippo.io/edwards25519 v1.1.0
+	gitlab.com/Blockdaemon/go-tsm-sdkv2/v64 v64.0.0
+)
 
 require (
-	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/bits-and-blooms/bitset v1.13.0 // indirect
 	github.com/consensys/bavard v0.1.13 // indirect
 	github.com/consensys/gnark-crypto v0.13.0 // indirect
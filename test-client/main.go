@@ -3,7 +3,9 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -14,6 +16,7 @@ import (
 
 	"example.com/tsmutils"
 	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
+	sdkutils "gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm/tsmutils"
 )
 
 var mobile0PublicKey = "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE2Bk6ZSVUhIStsXZsqyYidPy8vEQvLDVQ/YRgfgowgWFualE748OFoGwuGgE8C7L2zV4gX+1Ow1x/OTjqSSlh5A=="
@@ -21,6 +24,9 @@ var mobile1PublicKey = "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEkAzm+8yn+d0ypywEwtgN
 var tsmDynamicMob0 = tsm.Configuration{URL: "http://localhost:8510"}.WithAPIKeyAuthentication("apikey0")
 var tsmDynamicMob1 = tsm.Configuration{URL: "http://localhost:8511"}.WithAPIKeyAuthentication("apikey0")
 
+// appserver 의 key metadata 에 기록되는 사용자
+var testUserId = "test-user"
+
 type TSMNode struct {
 	Config    *tsm.Configuration
 	PublicKey string
@@ -75,7 +81,7 @@ func main() {
 	log.Printf("presignatureIds: %v\n", presignatureIds)
 	messageBytes := []byte(message)
 	msgHash := sha256.Sum256(messageBytes)
-	sig1 := finalizeSign(nodes[1], presignatureIds[0], msgHash[:])
+	sig1 := finalizeSign(nodes[1], presignatureIds[0], "hash", msgHash[:])
 
 	client0 := tsmutils.GetClientFromConfig(nodes[1].Config)
 	pubKey0, err := client0.Schnorr().PublicKey(context.TODO(), nodes[1].KeyId, nil)
@@ -93,7 +99,7 @@ func main() {
 	// dynamic node0 message 에 서명
 	presignatureIds = preSign(nodes[0], 1)
 	log.Printf("presignatureIds: %v\n", presignatureIds)
-	sig2 := finalizeSign(nodes[0], presignatureIds[0], msgHash[:])
+	sig2 := finalizeSign(nodes[0], presignatureIds[0], "hash", msgHash[:])
 
 	client1 := tsmutils.GetClientFromConfig(nodes[0].Config)
 	pubKey1, err := client1.Schnorr().PublicKey(context.TODO(), nodes[0].KeyId, nil)
@@ -108,7 +114,78 @@ func main() {
 		panic(node0Err)
 	}
 
+	// message mode 로 raw message 에 서명하면 표준 Ed25519 로 검증할 수 있습니다.
+	presignatureIds = preSign(nodes[0], 1)
+	log.Printf("presignatureIds: %v\n", presignatureIds)
+	sig3 := finalizeSign(nodes[0], presignatureIds[0], "message", messageBytes)
+
+	log.Printf("verify message mode signature with crypto/ed25519\n")
+	pkixPubKey, err := x509.ParsePKIXPublicKey(pubKey1)
+	if err != nil {
+		panic(err)
+	}
+	ed25519PubKey, ok := pkixPubKey.(ed25519.PublicKey)
+	if !ok {
+		panic("public key is not an ed25519 key")
+	}
+	if !ed25519.Verify(ed25519PubKey, messageBytes, sig3) {
+		panic("message mode signature is not a valid ed25519 signature")
+	}
+
 	log.Printf("All signatures are verified\n")
+
+	// dynamic1 의 key share 를 새로 만든다. keyId 와 public key 는 바뀌지 않는다.
+	reshareKeyId := client1ReshareKey(nodes[1].PublicKey, nodes[1].KeyId)
+	if reshareKeyId != nodes[1].KeyId {
+		panic("keyId changed after reshare")
+	}
+	resharedClient := tsmutils.GetClientFromConfig(nodes[1].Config)
+	if tsmutils.GetPubkeyStringFromClient(resharedClient, nodes[1].KeyId) != copyKeyResult.UserPublicKey {
+		panic("User public key changed after reshare")
+	}
+
+	// 이전 presignature 는 삭제되므로 새로 만들어 서명한다.
+	presignatureIds = preSign(nodes[1], 1)
+	sig4 := finalizeSign(nodes[1], presignatureIds[0], "hash", msgHash[:])
+	pubKey4, err := resharedClient.Schnorr().PublicKey(context.TODO(), nodes[1].KeyId, nil)
+	if err != nil {
+		panic(err)
+	}
+	if err := tsm.SchnorrVerifySignature(pubKey4, msgHash[:], sig4); err != nil {
+		panic(err)
+	}
+	log.Printf("Reshared key signature is verified\n")
+
+	// 기존 ed25519 wallet 을 dynamic0 으로 import 한다. keyId 의 public key 는 wallet 의 public key 와 같아야 한다.
+	walletPublicKey, walletPrivateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		panic(err)
+	}
+	walletPKIXPublicKey, err := x509.MarshalPKIXPublicKey(walletPublicKey)
+	if err != nil {
+		panic(err)
+	}
+	importedKey := TSMNode{
+		Config:    tsmDynamicMob0,
+		PublicKey: mobile0PublicKey,
+		KeyId:     client0ImportKey(mobile0PublicKey, walletPrivateKey, walletPKIXPublicKey),
+	}
+	importedClient := tsmutils.GetClientFromConfig(importedKey.Config)
+	importedPubKey, err := importedClient.Schnorr().PublicKey(context.TODO(), importedKey.KeyId, nil)
+	if err != nil {
+		panic(err)
+	}
+	if !bytes.Equal(importedPubKey, walletPKIXPublicKey) {
+		panic("imported key public key mismatch")
+	}
+
+	// import 한 key 로 서명하면 기존 wallet 의 public key 로 검증된다.
+	presignatureIds = preSign(importedKey, 1)
+	sig5 := finalizeSign(importedKey, presignatureIds[0], "message", messageBytes)
+	if !ed25519.Verify(walletPublicKey, messageBytes, sig5) {
+		panic("imported key signature is not valid for the wallet public key")
+	}
+	log.Printf("Imported key signature is verified\n")
 }
 
 func client0GenKey(nodePubKey string) *GetKeyResult {
@@ -185,6 +262,83 @@ func client1CopyKey(nodePubKey string, keyId string) *CopyKeyResult {
 	}
 }
 
+func client1ReshareKey(nodePubKey string, keyId string) string {
+	// appserver 에 요청하여 reshare session id 를 가져온다.
+	// player1, player2 가 reshare 대기 상태가 되면 player0 도 같은 keyId 로 참여한다.
+	sessionId := startReshareKeySession(nodePubKey, keyId)
+	player0PublicTenantKey, err := base64.StdEncoding.DecodeString(nodePubKey)
+	if err != nil {
+		panic(err)
+	}
+
+	dynamicPublicKeys := map[int][]byte{
+		0: player0PublicTenantKey,
+	}
+	players := []int{0, 1, 2}
+	sessionConfig := tsm.NewSessionConfig(sessionId, players, dynamicPublicKeys)
+
+	client := tsmutils.GetClientFromConfig(tsmDynamicMob1)
+	log.Printf("Resharing key. using client.Schnorr\n")
+	if err := client.Schnorr().Reshare(context.Background(), sessionConfig, keyId); err != nil {
+		panic(err)
+	}
+
+	// 완료되면 이전 key share 와 presignature 는 사용할 수 없다.
+	return keyId
+}
+
+func client0ImportKey(nodePubKey string, walletPrivateKey ed25519.PrivateKey, walletPKIXPublicKey []byte) string {
+	// 기존 private key 를 player 0/1/2 의 share 로 나누고, 각 share 를 해당 player 의 wrapping key 로 암호화한다.
+	// player1, player2 의 share 는 appserver 를 통해 전달하고 player0 은 자신의 share 로 참여한다.
+	client := tsmutils.GetClientFromConfig(tsmDynamicMob0)
+	player0WrappingKey, err := client.WrappingKey().WrappingKey(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	wrappingKeys := getWrappingKeys()
+	player1WrappingKey, err := base64.StdEncoding.DecodeString(wrappingKeys.Player1)
+	if err != nil {
+		panic(err)
+	}
+	player2WrappingKey, err := base64.StdEncoding.DecodeString(wrappingKeys.Player2)
+	if err != nil {
+		panic(err)
+	}
+
+	players := []int{0, 1, 2}
+	shares, err := sdkutils.ShamirSecretShare(1, players, "ED-25519", tsmutils.Ed25519PrivateKeyScalar(walletPrivateKey))
+	if err != nil {
+		panic(err)
+	}
+	wrappedShares := map[int][]byte{}
+	for player, wrappingKey := range map[int][]byte{0: player0WrappingKey, 1: player1WrappingKey, 2: player2WrappingKey} {
+		if wrappedShares[player], err = sdkutils.Wrap(tsmutils.ParseWrappingKey(wrappingKey), shares[player]); err != nil {
+			panic(err)
+		}
+	}
+
+	pkixPublicKey := base64.StdEncoding.EncodeToString(walletPKIXPublicKey)
+	sessionId := startImportKeySession(nodePubKey, pkixPublicKey, wrappedShares[1], wrappedShares[2])
+	player0PublicTenantKey, err := base64.StdEncoding.DecodeString(nodePubKey)
+	if err != nil {
+		panic(err)
+	}
+
+	dynamicPublicKeys := map[int][]byte{
+		0: player0PublicTenantKey,
+	}
+	sessionConfig := tsm.NewSessionConfig(sessionId, players, dynamicPublicKeys)
+
+	log.Printf("Importing key. using client.Schnorr\n")
+	keyId, err := client.Schnorr().ImportKeyShares(context.Background(), sessionConfig, 1, wrappedShares[0], nil, walletPKIXPublicKey, "")
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("imported keyId: %s\n", keyId)
+
+	return keyId
+}
+
 func preSign(node TSMNode, presignatureCount uint64) []string {
 	sessionId := startGeneratePreSignSignSession(node.PublicKey, node.KeyId)
 	player0PublicTenantKey, err := base64.StdEncoding.DecodeString(node.PublicKey)
@@ -207,10 +361,10 @@ func preSign(node TSMNode, presignatureCount uint64) []string {
 	return preSignatureId
 }
 
-func finalizeSign(node TSMNode, preSignatureId string, messageHash []byte) []byte {
+func finalizeSign(node TSMNode, preSignatureId string, mode string, messageHash []byte) []byte {
 
 	byteToStr := base64.StdEncoding.EncodeToString(messageHash)
-	partialSigns := getPartialSignResult(preSignatureId, node.KeyId, byteToStr)
+	partialSigns := getPartialSignResult(preSignatureId, node.KeyId, mode, byteToStr)
 	client := tsmutils.GetClientFromConfig(node.Config)
 
 	partialSignatures := make([][]byte, 0)
@@ -235,6 +389,7 @@ func finalizeSign(node TSMNode, preSignatureId string, messageHash []byte) []byt
 }
 
 type GenerateKeyRequestBody struct {
+	UserId    string `json:"userId"`
 	PublicKey string `json:"publicKey"`
 }
 
@@ -246,6 +401,7 @@ func startGenerateKeySession(publicKey string) string {
 
 	url := "http://localhost:3000/v1/tsm/generateKey"
 	addrReqBody := GenerateKeyRequestBody{
+		UserId:    testUserId,
 		PublicKey: publicKey,
 	}
 	value, _ := json.Marshal(addrReqBody)
@@ -283,6 +439,7 @@ func startGenerateKeySession(publicKey string) string {
 }
 
 type CopyKeyRequestBody struct {
+	UserId    string `json:"userId,omitempty"`
 	PublicKey string `json:"publicKey"`
 	KeyId     string `json:"keyId"`
 }
@@ -294,6 +451,7 @@ type CopyKeyResponse struct {
 func startCopyKeySession(publicKey string, existingKeyId string) string {
 	url := "http://localhost:3000/v1/tsm/copyKey"
 	addrReqBody := CopyKeyRequestBody{
+		UserId:    testUserId,
 		PublicKey: publicKey,
 		KeyId:     existingKeyId,
 	}
@@ -331,6 +489,147 @@ func startCopyKeySession(publicKey string, existingKeyId string) string {
 	return resObj.SessionId
 }
 
+type ReshareKeyResponse struct {
+	SessionId string `json:"sessionId"`
+	KeyId     string `json:"keyId"`
+}
+
+func startReshareKeySession(publicKey string, keyId string) string {
+	url := "http://localhost:3000/v1/tsm/reshareKey"
+	addrReqBody := CopyKeyRequestBody{
+		PublicKey: publicKey,
+		KeyId:     keyId,
+	}
+	value, _ := json.Marshal(addrReqBody)
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(value))
+	req.Header.Set("Content-Type", "application/json")
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("User-Agent", "ABC")
+
+	client := &http.Client{Timeout: time.Duration(3000) * time.Millisecond}
+	resp, err := client.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		panic(fmt.Errorf("failed to get session id. status code: %d", resp.StatusCode))
+	}
+
+	var resObj ReshareKeyResponse
+	err = json.Unmarshal(body, &resObj)
+	if err != nil {
+		panic(err)
+	}
+	if resObj.KeyId != keyId {
+		panic(fmt.Errorf("reshare keyId mismatch. expected: %s, got: %s", keyId, resObj.KeyId))
+	}
+
+	return resObj.SessionId
+}
+
+type WrappingKeysResponse struct {
+	Player1 string `json:"player1"`
+	Player2 string `json:"player2"`
+}
+
+func getWrappingKeys() *WrappingKeysResponse {
+	url := "http://localhost:3000/v1/tsm/wrappingKeys"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("User-Agent", "ABC")
+
+	client := &http.Client{Timeout: time.Duration(3000) * time.Millisecond}
+	resp, err := client.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		panic(fmt.Errorf("failed to get wrapping keys. status code: %d", resp.StatusCode))
+	}
+
+	var resObj WrappingKeysResponse
+	err = json.Unmarshal(body, &resObj)
+	if err != nil {
+		panic(err)
+	}
+
+	return &resObj
+}
+
+type ImportShare struct {
+	WrappedKeyShare string `json:"wrappedKeyShare"`
+}
+
+type ImportKeyRequestBody struct {
+	UserId        string      `json:"userId"`
+	PublicKey     string      `json:"publicKey"`
+	PKIXPublicKey string      `json:"pkixPublicKey"`
+	Player1       ImportShare `json:"player1"`
+	Player2       ImportShare `json:"player2"`
+}
+
+func startImportKeySession(publicKey string, pkixPublicKey string, player1Share []byte, player2Share []byte) string {
+	url := "http://localhost:3000/v1/tsm/importKey"
+	addrReqBody := ImportKeyRequestBody{
+		UserId:        testUserId,
+		PublicKey:     publicKey,
+		PKIXPublicKey: pkixPublicKey,
+		Player1:       ImportShare{WrappedKeyShare: base64.StdEncoding.EncodeToString(player1Share)},
+		Player2:       ImportShare{WrappedKeyShare: base64.StdEncoding.EncodeToString(player2Share)},
+	}
+	value, _ := json.Marshal(addrReqBody)
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(value))
+	req.Header.Set("Content-Type", "application/json")
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("User-Agent", "ABC")
+
+	client := &http.Client{Timeout: time.Duration(3000) * time.Millisecond}
+	resp, err := client.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		panic(fmt.Errorf("failed to get session id. status code: %d", resp.StatusCode))
+	}
+
+	var resObj GenerateKeyResponse
+	err = json.Unmarshal(body, &resObj)
+	if err != nil {
+		panic(err)
+	}
+
+	return resObj.SessionId
+}
+
 type PreSignRequestBody struct {
 	PublicKey string `json:"publicKey"`
 	KeyId     string `json:"keyId"`
@@ -386,19 +685,26 @@ func startGeneratePreSignSignSession(publicKey string, keyId string) string {
 type GetPartialSizeResultRequestBody struct {
 	PreSignatureId string `json:"preSignatureId"`
 	KeyId          string `json:"keyId"`
-	MessageHash    string `json:"messageHash"`
+	Mode           string `json:"mode"`
+	MessageHash    string `json:"messageHash,omitempty"`
+	Message        string `json:"message,omitempty"`
 }
 
 type GetPartialSignResultResponse struct {
 	PartialSignResult string `json:"partialSignResult" binding:"required" example:"zUhWR7jvWJoplMyFf35NHSdZXbtx"`
 }
 
-func getPartialSignResult(preSignatureId string, keyId string, messageHash string) string {
+func getPartialSignResult(preSignatureId string, keyId string, mode string, data string) string {
 	url := "http://localhost:3000/v1/tsm/finalizeSign"
 	addrReqBody := GetPartialSizeResultRequestBody{
 		PreSignatureId: preSignatureId,
 		KeyId:          keyId,
-		MessageHash:    messageHash,
+		Mode:           mode,
+	}
+	if mode == "message" {
+		addrReqBody.Message = data
+	} else {
+		addrReqBody.MessageHash = data
 	}
 	value, _ := json.Marshal(addrReqBody)
 
@@ -2,10 +2,15 @@ package tsmutils
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 
+	"filippo.io/edwards25519"
 	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v64/tsm"
 )
 
@@ -71,3 +76,31 @@ func KeyListing(configs []*tsm.Configuration) {
 		fmt.Printf("node: %d, keyIDs %v\n", idx, keyIDs)
 	}
 }
+
+// Ed25519PrivateKeyScalar returns the secret scalar of an ed25519 private key in big endian,
+// as expected by tsmutils.ShamirSecretShare of the SDK.
+func Ed25519PrivateKeyScalar(privateKey ed25519.PrivateKey) []byte {
+	digest := sha512.Sum512(privateKey.Seed())
+	scalar, err := edwards25519.NewScalar().SetBytesWithClamping(digest[:32])
+	if err != nil {
+		panic(err)
+	}
+	littleEndian := scalar.Bytes()
+	bigEndian := make([]byte, len(littleEndian))
+	for i := range littleEndian {
+		bigEndian[i] = littleEndian[len(littleEndian)-1-i]
+	}
+	return bigEndian
+}
+
+func ParseWrappingKey(der []byte) *rsa.PublicKey {
+	publicKey, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		panic(err)
+	}
+	rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		panic("wrapping key is not an RSA key")
+	}
+	return rsaPublicKey
+}